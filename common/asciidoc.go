@@ -0,0 +1,401 @@
+package common
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// HTMLConverter turns scraped HTML into AsciiDoc: paragraphs, headings,
+// lists, tables, blockquotes, preformatted text, emphasis and links.
+type HTMLConverter struct {
+	// Link writes a link with its already converted text, for example as a
+	// wikilink. When it is nil or returns "" absolute links become URL
+	// macros and relative ones are left as their text.
+	Link func(href string, text string) string
+}
+
+var extraNewlines = regexp.MustCompile(`\n{3,}`)
+
+func (c HTMLConverter) Convert(nodes ...*html.Node) string {
+	w := &asciiDocWriter{converter: c}
+	for _, node := range nodes {
+		w.node(node)
+	}
+
+	lines := strings.Split(w.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+
+	return strings.TrimSpace(extraNewlines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+type asciiDocWriter struct {
+	strings.Builder
+	converter HTMLConverter
+	// markers of the lists being written, "*" or "." for each level
+	lists []string
+	// where the text of the current list item starts
+	itemStart int
+	// where the last list ended, a list right after it needs a separator
+	// not to become part of it
+	listEnd int
+	pre     bool
+}
+
+var headingLevels = map[string]string{
+	"h1": "==", "h2": "===", "h3": "====", "h4": "=====", "h5": "======", "h6": "======",
+}
+
+func (w *asciiDocWriter) node(node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		w.text(node.Data)
+	case html.DocumentNode:
+		w.children(node)
+	case html.ElementNode:
+		w.element(node)
+	}
+}
+
+func (w *asciiDocWriter) children(node *html.Node) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		w.node(child)
+	}
+}
+
+func (w *asciiDocWriter) element(node *html.Node) {
+	switch node.Data {
+	case "script", "style", "noscript", "iframe", "form", "img":
+		// nothing to read there
+
+	case "br":
+		w.WriteByte('\n')
+
+	case "p", "div", "section", "article", "center":
+		w.block()
+		w.children(node)
+		w.block()
+
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		w.block()
+		w.WriteString(headingLevels[node.Data] + " " + strings.TrimSpace(w.inline(node)))
+		w.block()
+
+	case "b", "strong":
+		w.emphasis(node, "*")
+	case "i", "em", "cite":
+		w.emphasis(node, "_")
+	case "code", "tt", "kbd":
+		w.emphasis(node, "`")
+
+	case "a":
+		inner := w.inline(node)
+		text := strings.TrimSpace(inner)
+		if text == "" {
+			w.text(inner)
+			return
+		}
+
+		if unicode.IsSpace(firstRune(inner)) && !w.endsWithSpace() {
+			w.WriteByte(' ')
+		}
+		w.WriteString(w.link(attr(node, "href"), text))
+		if unicode.IsSpace(lastRune(inner)) {
+			w.WriteByte(' ')
+		}
+
+	case "ul", "ol":
+		marker := "*"
+		if node.Data == "ol" {
+			marker = "."
+		}
+
+		if len(w.lists) == 0 {
+			w.block()
+			if w.Len() > 0 && w.Len() == w.listEnd {
+				w.WriteString("//-\n")
+			}
+		}
+		w.lists = append(w.lists, marker)
+		w.children(node)
+		w.lists = w.lists[:len(w.lists)-1]
+		if len(w.lists) == 0 {
+			w.block()
+			w.listEnd = w.Len()
+		}
+
+	case "li":
+		w.line()
+		level := max(len(w.lists), 1)
+		marker := "*"
+		if len(w.lists) > 0 {
+			marker = w.lists[level-1]
+		}
+		w.WriteString(strings.Repeat(marker, level) + " ")
+		w.itemStart = w.Len()
+		w.children(node)
+		w.line()
+
+	case "blockquote":
+		inner := HTMLConverter{Link: w.converter.Link}.Convert(childNodes(node)...)
+		if inner == "" {
+			return
+		}
+		w.block()
+		w.WriteString("____\n" + inner + "\n____")
+		w.block()
+
+	case "pre":
+		w.block()
+		w.WriteString("----\n")
+		w.pre = true
+		w.children(node)
+		w.pre = false
+		w.line()
+		w.WriteString("----")
+		w.block()
+
+	case "hr":
+		w.block()
+		w.WriteString("'''")
+		w.block()
+
+	case "table":
+		w.table(node)
+
+	default:
+		// span, font, small and the like only matter for their text
+		w.children(node)
+	}
+}
+
+// text writes text with its whitespace collapsed like a browser would
+func (w *asciiDocWriter) text(s string) {
+	if w.pre {
+		w.WriteString(s)
+		return
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		if s != "" && !w.endsWithSpace() {
+			w.WriteByte(' ')
+		}
+		return
+	}
+
+	if unicode.IsSpace(firstRune(s)) && !w.endsWithSpace() {
+		w.WriteByte(' ')
+	}
+	w.WriteString(strings.Join(fields, " "))
+	if unicode.IsSpace(lastRune(s)) {
+		w.WriteByte(' ')
+	}
+}
+
+// emphasis wraps the text of node in a constrained marker like *bold*, or
+// an unconstrained one like **bold** when it touches a word character
+func (w *asciiDocWriter) emphasis(node *html.Node, marker string) {
+	inner := w.inline(node)
+	trimmed := strings.TrimSpace(inner)
+	if trimmed == "" {
+		w.text(inner)
+		return
+	}
+
+	if unicode.IsSpace(firstRune(inner)) && !w.endsWithSpace() {
+		w.WriteByte(' ')
+	}
+
+	before := lastRune(w.String())
+	after := firstRune(nextText(node))
+	if isWordRune(before) || isWordRune(after) {
+		marker += marker
+	}
+
+	w.WriteString(marker + trimmed + marker)
+
+	if unicode.IsSpace(lastRune(inner)) {
+		w.WriteByte(' ')
+	}
+}
+
+func (w *asciiDocWriter) link(href string, text string) string {
+	if w.converter.Link != nil {
+		if link := w.converter.Link(href, text); link != "" {
+			return link
+		}
+	}
+
+	if strings.HasPrefix(href, "http://") || strings.HasPrefix(href, "https://") {
+		return href + "[" + text + "]"
+	}
+
+	return text
+}
+
+func (w *asciiDocWriter) table(node *html.Node) {
+	var rows [][]string
+	header := false
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+
+			switch child.Data {
+			case "tr":
+				var row []string
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type != html.ElementNode || (cell.Data != "td" && cell.Data != "th") {
+						continue
+					}
+					if cell.Data == "th" && len(rows) == 0 {
+						header = true
+					}
+
+					text := strings.Join(strings.Fields(w.inline(cell)), " ")
+					row = append(row, strings.ReplaceAll(text, "|", "\\|"))
+				}
+				if len(row) > 0 {
+					rows = append(rows, row)
+				}
+			case "thead", "tbody", "tfoot":
+				walk(child)
+			}
+		}
+	}
+	walk(node)
+
+	if len(rows) == 0 {
+		return
+	}
+
+	w.block()
+	if header {
+		w.WriteString("[%header]\n")
+	}
+	w.WriteString("|===\n")
+	for _, row := range rows {
+		for _, cell := range row {
+			w.WriteString("|" + cell + " ")
+		}
+		w.WriteString("\n")
+	}
+	w.WriteString("|===")
+	w.block()
+}
+
+// inline converts the children of node on their own, for the places where
+// they become part of something else
+func (w *asciiDocWriter) inline(node *html.Node) string {
+	inner := &asciiDocWriter{converter: w.converter, pre: w.pre}
+	inner.children(node)
+
+	s := inner.String()
+	if w.pre {
+		return s
+	}
+
+	// headings, links and cells are one line
+	s = strings.Join(strings.Fields(s), " ")
+	if text := inner.String(); s != "" {
+		if unicode.IsSpace(firstRune(text)) {
+			s = " " + s
+		}
+		if unicode.IsSpace(lastRune(text)) {
+			s += " "
+		}
+	}
+
+	return s
+}
+
+// block makes what comes next start a paragraph of its own
+func (w *asciiDocWriter) block() {
+	if w.Len() == 0 || len(w.lists) > 0 {
+		w.line()
+		return
+	}
+	s := w.String()
+	if !strings.HasSuffix(s, "\n\n") {
+		if strings.HasSuffix(s, "\n") {
+			w.WriteByte('\n')
+		} else {
+			w.WriteString("\n\n")
+		}
+	}
+}
+
+// line makes what comes next start a line of its own
+func (w *asciiDocWriter) line() {
+	if len(w.lists) > 0 && w.Len() == w.itemStart {
+		// the item's text goes right after its marker
+		return
+	}
+	if w.Len() > 0 && !strings.HasSuffix(w.String(), "\n") {
+		w.WriteByte('\n')
+	}
+}
+
+func (w *asciiDocWriter) endsWithSpace() bool {
+	return w.Len() == 0 || unicode.IsSpace(lastRune(w.String()))
+}
+
+func attr(node *html.Node, key string) string {
+	for _, a := range node.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+
+	return ""
+}
+
+func childNodes(node *html.Node) []*html.Node {
+	var nodes []*html.Node
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		nodes = append(nodes, child)
+	}
+
+	return nodes
+}
+
+// nextText is the text right after node, to tell whether it is followed by
+// a word
+func nextText(node *html.Node) string {
+	for next := node.NextSibling; next != nil; next = next.NextSibling {
+		switch next.Type {
+		case html.TextNode:
+			return next.Data
+		case html.ElementNode:
+			if next.FirstChild != nil && next.FirstChild.Type == html.TextNode {
+				return next.FirstChild.Data
+			}
+			return ""
+		}
+	}
+
+	return ""
+}
+
+func firstRune(s string) rune {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
+
+func lastRune(s string) rune {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
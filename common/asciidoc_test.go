@@ -0,0 +1,63 @@
+package common
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+// TestConvertHTML converts every testdata/html/*.html and compares it with
+// the .adoc file next to it
+func TestConvertHTML(t *testing.T) {
+	fixtures, err := filepath.Glob("testdata/html/*.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	converter := HTMLConverter{
+		Link: func(href string, text string) string {
+			if strings.Contains(href, "artist.asp") {
+				return "[[" + text + "]]"
+			}
+			return ""
+		},
+	}
+
+	for _, fixture := range fixtures {
+		t.Run(filepath.Base(fixture), func(t *testing.T) {
+			f, err := os.Open(fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			doc, err := html.Parse(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := converter.Convert(doc) + "\n"
+
+			golden := strings.TrimSuffix(fixture, ".html") + ".adoc"
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(expected) {
+				t.Errorf("got:\n%s\nexpected:\n%s", got, expected)
+			}
+		})
+	}
+}
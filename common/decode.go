@@ -0,0 +1,181 @@
+package common
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/unicode/norm"
+)
+
+// DecodeHTML reads a page into clean UTF-8, whatever it is encoded in.
+//
+// The encoding is the one declared by a byte order mark, the Content-Type
+// header or a <meta> tag, in that order. Pages that declare nothing are
+// sniffed, and anything that isn't UTF-8 is taken as Windows-1252, like
+// browsers do. Bytes that aren't valid in a page declared as UTF-8 are read
+// as Windows-1252 too, since that's how they usually got there.
+//
+// The text is then cleaned with CleanText.
+func DecodeHTML(r io.Reader, contentType string) (io.Reader, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+
+	text, err := decodeBytes(body, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.NewReader(CleanText(text)), nil
+}
+
+func decodeBytes(body []byte, contentType string) (string, error) {
+	encoding, name, _ := charset.DetermineEncoding(body, contentType)
+
+	// only the start of the page is sniffed, a page that is all ASCII there
+	// may still be UTF-8 further on
+	if name == "windows-1252" && hasHighBit(body) && utf8.Valid(body) {
+		name = "utf-8"
+	}
+
+	if name == "utf-8" {
+		return decodeLenientUTF8(body), nil
+	}
+
+	decoded, err := encoding.NewDecoder().Bytes(body)
+	if err != nil {
+		return "", fmt.Errorf("decode %s: %w", name, err)
+	}
+
+	return string(decoded), nil
+}
+
+// decodeLenientUTF8 decodes UTF-8 reading the bytes that aren't valid in it
+// as Windows-1252
+func decodeLenientUTF8(body []byte) string {
+	body = trimBOM(body)
+	if utf8.Valid(body) {
+		return string(body)
+	}
+
+	text := strings.Builder{}
+	for len(body) > 0 {
+		r, size := utf8.DecodeRune(body)
+		if r == utf8.RuneError && size == 1 {
+			r = charmap.Windows1252.DecodeByte(body[0])
+		}
+		text.WriteRune(r)
+		body = body[size:]
+	}
+
+	return text.String()
+}
+
+func trimBOM(body []byte) []byte {
+	if len(body) >= 3 && body[0] == 0xef && body[1] == 0xbb && body[2] == 0xbf {
+		return body[3:]
+	}
+
+	return body
+}
+
+func hasHighBit(body []byte) bool {
+	for _, b := range body {
+		if b >= 0x80 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CleanText repairs mojibake, UTF-8 that was read as Windows-1252 on its
+// way to us like "CafÃ©" for "Café", drops invalid characters and
+// normalizes to NFC so the same text is always the same bytes.
+func CleanText(s string) string {
+	s = strings.ToValidUTF8(s, "")
+
+	// text that went through the wrong decoding twice needs two repairs
+	for range 3 {
+		repaired := repairMojibake(s)
+		if repaired == s {
+			break
+		}
+		s = repaired
+	}
+
+	return norm.NFC.String(s)
+}
+
+// repairMojibake turns each run of non-ASCII characters that starts like
+// mojibake back into the bytes Windows-1252 would have for them, keeping the
+// result when those bytes are valid UTF-8 for Latin letters or punctuation.
+// Text that really is in those characters almost never is, a lone "é" is
+// not while "Ã©" is.
+func repairMojibake(s string) string {
+	out := strings.Builder{}
+
+	for len(s) > 0 {
+		start := strings.IndexFunc(s, func(r rune) bool { return r >= utf8.RuneSelf })
+		if start == -1 {
+			out.WriteString(s)
+			break
+		}
+		out.WriteString(s[:start])
+		s = s[start:]
+
+		end := strings.IndexFunc(s, func(r rune) bool { return r < utf8.RuneSelf })
+		if end == -1 {
+			end = len(s)
+		}
+
+		out.WriteString(repairRun(s[:end]))
+		s = s[end:]
+	}
+
+	return out.String()
+}
+
+// mojibakeLeads are how the Windows-1252 reading of UTF-8 Latin letters and
+// punctuation starts, "Ã©" for "é" or "â€™" for "’"
+var mojibakeLeads = []string{"Ã", "Â", "Ä", "Å", "â€", "â‚", "â„"}
+
+func repairRun(run string) string {
+	if !slices.ContainsFunc(mojibakeLeads, func(lead string) bool { return strings.HasPrefix(run, lead) }) {
+		return run
+	}
+
+	encoded := make([]byte, 0, len(run))
+	for _, r := range run {
+		b, ok := charmap.Windows1252.EncodeRune(r)
+		if !ok {
+			return run
+		}
+		encoded = append(encoded, b)
+	}
+
+	if !utf8.Valid(encoded) {
+		return run
+	}
+
+	repaired := string(encoded)
+	for _, r := range repaired {
+		if !repairable(r) {
+			return run
+		}
+	}
+
+	return repaired
+}
+
+// repairable is whether a character is something mojibake usually comes
+// from: Latin letters, general punctuation, the euro and the trademark sign
+func repairable(r rune) bool {
+	return (r >= 0xa0 && r <= 0x24f) || (r >= 0x2000 && r <= 0x206f) || r == '€' || r == '™'
+}
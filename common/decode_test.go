@@ -0,0 +1,68 @@
+package common
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDecodeHTML(t *testing.T) {
+	for _, test := range []struct {
+		fixture     string
+		contentType string
+		expected    string
+	}{
+		{"utf-8.html", "text/html", "Björk — “Café”"},
+		{"windows-1252.html", "text/html", "Björk — “Café”"},
+		{"iso-8859-1.html", "text/html; charset=ISO-8859-1", "Björk, Café"},
+		{"undeclared-windows-1252.html", "", "Björk — “Café”"},
+		{"late-utf-8.html", "", "Björk — “Café”"},
+		{"mislabeled.html", "text/html; charset=utf-8", "Björk — “Café”"},
+		{"mojibake.html", "", "Björk — Café"},
+		{"shift_jis.html", "", "プログレ"},
+		{"utf-16le.html", "text/html; charset=utf-8", "Björk — “Café”"},
+		{"nfd.html", "", "Björk, Café"},
+	} {
+		t.Run(test.fixture, func(t *testing.T) {
+			f, err := os.Open("testdata/encodings/" + test.fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			r, err := DecodeHTML(f, test.contentType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.Contains(string(decoded), test.expected) {
+				t.Errorf("expected %q in:\n%s", test.expected, decoded)
+			}
+		})
+	}
+}
+
+func TestCleanText(t *testing.T) {
+	for input, expected := range map[string]string{
+		"CafÃ©":                  "Café",
+		"CafÃƒÂ©":                "Café",
+		"Ã€ la carte":            "À la carte",
+		"À la carte, Ñandú":      "À la carte, Ñandú",
+		"Emerson, Lake & Palmer": "Emerson, Lake & Palmer",
+		"Björk":                 "Björk",
+		"bad \xff byte":          "bad  byte",
+		// real text whose characters happen to be valid UTF-8 in Windows-1252
+		"Fuß“":        "Fuß“",
+		"CAFÉ\u00a0!": "CAFÉ\u00a0!",
+		"SÃO PAULO":   "SÃO PAULO",
+	} {
+		if got := CleanText(input); got != expected {
+			t.Errorf("CleanText(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
@@ -12,6 +12,7 @@ func HttpGet(url string) (*http.Response, error) {
 	}
 
 	if resp.StatusCode != 200 {
+		resp.Body.Close()
 		return nil, fmt.Errorf("status code: %d", resp.StatusCode)
 	}
 
@@ -0,0 +1,181 @@
+package common
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/nbd-wtf/go-nostr/nip54"
+)
+
+// IdentifierIndex remembers the identifier each item of an importer was
+// published under, by the ID the source gives it, so that items with the same
+// title get distinct identifiers and links can point exactly at them. How an
+// identifier is chosen is up to the importer; the index keeps track of which
+// ones are taken and qualifies the ones that are.
+//
+// It is kept as JSON lines, one per assignment, later lines winning.
+type IdentifierIndex[ID comparable] struct {
+	file   *os.File
+	byID   map[ID]IdentifierEntry[ID]
+	byBare map[string][]ID
+	used   map[string]ID
+	// indexes whose identifiers are taken too, like the ones of the same
+	// author in other languages
+	Siblings []*IdentifierIndex[ID]
+}
+
+type IdentifierEntry[ID comparable] struct {
+	ID    ID     `json:"id"`
+	Title string `json:"title,omitempty"`
+	// the identifier for the title alone
+	Bare       string   `json:"bare,omitempty"`
+	Identifier string   `json:"identifier"`
+	Qualifiers []string `json:"qualifiers,omitempty"`
+}
+
+func LoadIdentifierIndex[ID comparable](name string) (*IdentifierIndex[ID], error) {
+	path, err := StatePath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := &IdentifierIndex[ID]{
+		byID:   make(map[ID]IdentifierEntry[ID]),
+		byBare: make(map[string][]ID),
+		used:   make(map[string]ID),
+	}
+
+	f, err := os.Open(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("open identifier index: %w", err)
+	}
+	if err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var entry IdentifierEntry[ID]
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("decode identifier index: %w", err)
+			}
+			idx.set(entry)
+		}
+		f.Close()
+
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("read identifier index: %w", err)
+		}
+	}
+
+	idx.file, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open identifier index: %w", err)
+	}
+
+	return idx, nil
+}
+
+func (idx *IdentifierIndex[ID]) Close() error {
+	return idx.file.Close()
+}
+
+// Entry is what was recorded for an item
+func (idx *IdentifierIndex[ID]) Entry(id ID) (IdentifierEntry[ID], bool) {
+	entry, ok := idx.byID[id]
+	return entry, ok
+}
+
+// Lookup returns the identifier an item was published under
+func (idx *IdentifierIndex[ID]) Lookup(id ID) (string, bool) {
+	entry, ok := idx.byID[id]
+	return entry.Identifier, ok
+}
+
+// WithBare lists the items whose titles have the same bare identifier
+func (idx *IdentifierIndex[ID]) WithBare(bare string) []ID {
+	return idx.byBare[bare]
+}
+
+// Taken is whether an identifier belongs to an item other than id, or to
+// anything in a sibling index
+func (idx *IdentifierIndex[ID]) Taken(identifier string, id ID) bool {
+	if owner, taken := idx.used[identifier]; taken && owner != id {
+		return true
+	}
+
+	for _, sibling := range idx.Siblings {
+		if _, taken := sibling.used[identifier]; taken {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Qualify appends the entry's qualifiers (e.g. a year) to its bare identifier
+// one by one until the result isn't taken, with the ID as last resort
+func (idx *IdentifierIndex[ID]) Qualify(entry IdentifierEntry[ID]) string {
+	identifier := entry.Bare
+	for _, qualifier := range entry.Qualifiers {
+		if qualifier = NormalizeQualifier(qualifier); qualifier == "" {
+			continue
+		}
+
+		identifier = strings.TrimRight(identifier, "-") + "-" + qualifier
+		if !idx.Taken(identifier, entry.ID) {
+			return identifier
+		}
+	}
+
+	return strings.TrimRight(identifier, "-") + "-" + NormalizeQualifier(fmt.Sprint(entry.ID))
+}
+
+// Record keeps an entry, replacing whatever the item had before
+func (idx *IdentifierIndex[ID]) Record(entry IdentifierEntry[ID]) error {
+	idx.set(entry)
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if _, err := idx.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write identifier index: %w", err)
+	}
+
+	return nil
+}
+
+func (idx *IdentifierIndex[ID]) set(entry IdentifierEntry[ID]) {
+	if previous, ok := idx.byID[entry.ID]; ok {
+		if idx.used[previous.Identifier] == entry.ID {
+			delete(idx.used, previous.Identifier)
+		}
+
+		ids := idx.byBare[previous.Bare]
+		for i, id := range ids {
+			if id == entry.ID {
+				idx.byBare[previous.Bare] = append(ids[:i:i], ids[i+1:]...)
+				break
+			}
+		}
+	}
+
+	idx.byID[entry.ID] = entry
+	idx.byBare[entry.Bare] = append(idx.byBare[entry.Bare], entry.ID)
+	idx.used[entry.Identifier] = entry.ID
+}
+
+var repeatedDashes = regexp.MustCompile(`-{2,}`)
+
+// NormalizeQualifier is nip54.NormalizeIdentifier for the parts appended to
+// an identifier, without the dashes punctuation leaves so that "Lake &
+// Palmer" is "lake-palmer". Bare identifiers must stay as nip54 makes them,
+// that's what plain wikilinks to a title resolve to.
+func NormalizeQualifier(qualifier string) string {
+	return strings.Trim(repeatedDashes.ReplaceAllString(nip54.NormalizeIdentifier(qualifier), "-"), "-")
+}
@@ -0,0 +1,49 @@
+package common
+
+import (
+	"os"
+	"testing"
+)
+
+func TestIdentifierIndexQualify(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	idx, err := LoadIdentifierIndex[uint64]("test.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	if err := idx.Record(IdentifierEntry[uint64]{ID: 1, Bare: "yes", Identifier: "yes"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Record(IdentifierEntry[uint64]{ID: 2, Bare: "yes", Identifier: "yes-united-kingdom"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		entry    IdentifierEntry[uint64]
+		expected string
+	}{
+		{IdentifierEntry[uint64]{ID: 3, Bare: "yes", Qualifiers: []string{"Brazil"}}, "yes-brazil"},
+		{IdentifierEntry[uint64]{ID: 3, Bare: "yes", Qualifiers: []string{"United Kingdom", "1968"}}, "yes-united-kingdom-1968"},
+		{IdentifierEntry[uint64]{ID: 3, Bare: "yes", Qualifiers: []string{"United Kingdom"}}, "yes-united-kingdom-3"},
+		{IdentifierEntry[uint64]{ID: 2, Bare: "yes", Qualifiers: []string{"United Kingdom"}}, "yes-united-kingdom"},
+		{IdentifierEntry[uint64]{ID: 4, Bare: "foxtrot--album-", Qualifiers: []string{"Lake & Palmer"}}, "foxtrot--album-lake-palmer"},
+	} {
+		if got := idx.Qualify(test.entry); got != test.expected {
+			t.Errorf("Qualify(%+v) = %s, expected %s", test.entry, got, test.expected)
+		}
+	}
+
+	if !idx.Taken("yes", 2) || idx.Taken("yes", 1) || idx.Taken("no", 2) {
+		t.Error("Taken doesn't tell owners apart")
+	}
+}
@@ -0,0 +1,17 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// StatePath returns where an importer keeps a file it needs between runs,
+// creating the state directory if it doesn't exist
+func StatePath(name string) (string, error) {
+	if err := os.MkdirAll("state", 0755); err != nil {
+		return "", fmt.Errorf("create state directory: %w", err)
+	}
+
+	return filepath.Join("state", name), nil
+}
@@ -9,6 +9,7 @@ require (
 	github.com/nbd-wtf/go-nostr v0.50.0
 	github.com/urfave/cli/v3 v3.0.0-beta1
 	golang.org/x/net v0.34.0
+	golang.org/x/text v0.21.0
 )
 
 require (
@@ -36,5 +37,4 @@ require (
 	github.com/tidwall/pretty v1.2.1 // indirect
 	golang.org/x/exp v0.0.0-20241204233417-43b7b7cde48d // indirect
 	golang.org/x/sys v0.29.0 // indirect
-	golang.org/x/text v0.21.0 // indirect
 )
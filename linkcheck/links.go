@@ -0,0 +1,33 @@
+package linkcheck
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/nbd-wtf/go-nostr/nip54"
+)
+
+var wikilinkRe = regexp.MustCompile(`\[\[([^\[\]|]+)(?:\|[^\[\]]*)?\]\]`)
+
+// extractWikilinks returns the normalized identifiers of all [[target]] and
+// [[target|label]] links found in an article, in order of appearance.
+func extractWikilinks(content string) []string {
+	var targets []string
+	for _, match := range wikilinkRe.FindAllStringSubmatch(content, -1) {
+		if target := normalizeTarget(match[1]); target != "" {
+			targets = append(targets, target)
+		}
+	}
+
+	return targets
+}
+
+// normalizeTarget turns a wikilink target into the "d" identifier it points
+// to, ignoring section anchors.
+func normalizeTarget(target string) string {
+	if idx := strings.Index(target, "#"); idx != -1 {
+		target = target[:idx]
+	}
+
+	return nip54.NormalizeIdentifier(target)
+}
@@ -0,0 +1,77 @@
+package linkcheck
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+func TestExtractWikilinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "plain and piped links",
+			input:    "[[Helena Petrovna Blavatsky|H. P. Blavatsky]]'s writing room at [[Adyar (campus)|Adyar]] and [[Master]].",
+			expected: []string{"helena-petrovna-blavatsky", "adyar--campus-", "master"},
+		},
+		{
+			name:     "section anchors are ignored",
+			input:    "see [[Pink Floyd#Discography|their albums]]",
+			expected: []string{"pink-floyd"},
+		},
+		{
+			name:     "same page anchors and empty links",
+			input:    "[[#History]] [[]] [[ ]]",
+			expected: nil,
+		},
+		{
+			name:     "definition lists",
+			input:    "Genres::\n[[Drama]]\n[[United States of America|US]]",
+			expected: []string{"drama", "united-states-of-america"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractWikilinks(tt.input)
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("extractWikilinks() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	events := []*nostr.Event{
+		{PubKey: "a", Kind: 30818, Tags: nostr.Tags{{"d", "hamlet"}}, Content: "[[William Shakespeare]] [[Drama]] [[Drama]]"},
+		{PubKey: "a", Kind: 30818, Tags: nostr.Tags{{"d", "drama"}}, Content: "[[Hamlet]]"},
+		{PubKey: "b", Kind: 30818, Tags: nostr.Tags{{"d", "william-shakespeare"}}, Content: "[[Hamlet]] [[Macbeth]]"},
+		{PubKey: "b", Kind: 30819, Tags: nostr.Tags{{"d", "shakespeare"}, {"redirect", "william-shakespeare"}}},
+		{PubKey: "b", Kind: 30818, Tags: nostr.Tags{{"d", "the-bard"}}, Content: "[[Shakespeare]] [[Macbeth]]"},
+	}
+
+	reports := check(events)
+	if len(reports) != 2 {
+		t.Fatalf("expected 2 reports, got %d", len(reports))
+	}
+
+	a := reports[0]
+	if a.Articles != 2 || a.Links != 4 || a.Targets != 3 || len(a.Dangling) != 0 {
+		t.Errorf("unexpected report for a: %+v", a)
+	}
+
+	b := reports[1]
+	if b.Articles != 2 || b.Links != 4 || b.Targets != 3 {
+		t.Errorf("unexpected report for b: %+v", b)
+	}
+	if len(b.Dangling) != 1 || b.Dangling[0] != (DanglingLink{Target: "macbeth", Count: 2}) {
+		t.Errorf("unexpected dangling links for b: %+v", b.Dangling)
+	}
+	if b.DanglingCount() != 2 {
+		t.Errorf("DanglingCount() = %d, want 2", b.DanglingCount())
+	}
+}
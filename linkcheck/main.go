@@ -0,0 +1,215 @@
+package linkcheck
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+	"github.com/urfave/cli/v3"
+)
+
+// importerKeys are the environment variables holding the keys each importer
+// publishes with, used to give report sections a readable name. The mediawiki
+// and progarchives importers both sign with NOSTR_KEY, so their events can't
+// be told apart and share one section.
+var importerKeys = []string{
+	"NOSTR_KEY",
+	"TMDB_NOSTR_KEY",
+	"OMDB_NOSTR_KEY",
+	"BEHINDTHENAME_NOSTR_KEY",
+}
+
+func HandleLinkCheck(ctx context.Context, logger *log.Logger, c *cli.Command) error {
+	authors, err := parseAuthors(c.StringSlice("author"))
+	if err != nil {
+		return err
+	}
+
+	relays := c.StringSlice("relay")
+	file := c.String("file")
+
+	if file == "" && len(relays) == 0 {
+		return fmt.Errorf("either --file or --relay is required")
+	}
+
+	var events []*nostr.Event
+	if file != "" {
+		events, err = loadEvents(file, authors)
+		if err != nil {
+			return fmt.Errorf("load events from %s: %w", file, err)
+		}
+	} else {
+		events = fetchEvents(ctx, logger, relays, authors)
+	}
+
+	logger.Printf("Checking wikilinks in %d events\n", len(events))
+
+	names := importerNames()
+	limit := int(c.Uint("limit"))
+
+	for _, report := range check(latest(events)) {
+		name := report.PubKey
+		if label, ok := names[report.PubKey]; ok {
+			name = label + " " + report.PubKey
+		}
+
+		logger.Printf(
+			"[%s] articles=%d links=%d targets=%d dangling targets=%d dangling links=%d\n",
+			name,
+			report.Articles,
+			report.Links,
+			report.Targets,
+			len(report.Dangling),
+			report.DanglingCount(),
+		)
+
+		for i, dangling := range report.Dangling {
+			if limit > 0 && i >= limit {
+				logger.Printf("  ... and %d more\n", len(report.Dangling)-limit)
+
+				break
+			}
+
+			logger.Printf("  %6d  %s\n", dangling.Count, dangling.Target)
+		}
+	}
+
+	return nil
+}
+
+func parseAuthors(values []string) ([]string, error) {
+	authors := make([]string, 0, len(values))
+	for _, value := range values {
+		if nostr.IsValidPublicKey(value) {
+			authors = append(authors, value)
+
+			continue
+		}
+
+		prefix, decoded, err := nip19.Decode(value)
+		if err != nil || prefix != "npub" {
+			return nil, fmt.Errorf("invalid author %s", value)
+		}
+
+		authors = append(authors, decoded.(string))
+	}
+
+	return authors, nil
+}
+
+func importerNames() map[string]string {
+	names := make(map[string]string)
+	for _, env := range importerKeys {
+		key := os.Getenv(env)
+		if key == "" {
+			continue
+		}
+
+		if pub, err := nostr.GetPublicKey(key); err == nil {
+			names[pub] = env
+		}
+	}
+
+	return names
+}
+
+// loadEvents reads events as JSON lines, like those written by `nak req`
+func loadEvents(file string, authors []string) ([]*nostr.Event, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []*nostr.Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		evt := &nostr.Event{}
+		if err := json.Unmarshal(scanner.Bytes(), evt); err != nil {
+			return nil, err
+		}
+
+		if evt.Kind != 30818 && evt.Kind != 30819 {
+			continue
+		}
+
+		if len(authors) > 0 && !slices.Contains(authors, evt.PubKey) {
+			continue
+		}
+
+		events = append(events, evt)
+	}
+
+	return events, scanner.Err()
+}
+
+// fetchEvents pages backwards through all wiki articles and redirects on the
+// given relays
+func fetchEvents(ctx context.Context, logger *log.Logger, relays []string, authors []string) []*nostr.Event {
+	pool := nostr.NewSimplePool(ctx)
+
+	filter := nostr.Filter{
+		Kinds:   []int{30818, 30819},
+		Authors: authors,
+		Limit:   500,
+	}
+
+	seen := make(map[string]struct{})
+	var events []*nostr.Event
+	for {
+		oldest := nostr.Now()
+		added := 0
+
+		for ie := range pool.FetchMany(ctx, relays, filter) {
+			if _, ok := seen[ie.ID]; ok {
+				continue
+			}
+			seen[ie.ID] = struct{}{}
+
+			events = append(events, ie.Event)
+			added++
+
+			if ie.CreatedAt < oldest {
+				oldest = ie.CreatedAt
+			}
+		}
+
+		logger.Printf("Fetched %d events\n", len(events))
+
+		if added == 0 {
+			return events
+		}
+
+		// events with the same timestamp are skipped by the seen check
+		filter.Until = &oldest
+	}
+}
+
+// latest keeps only the newest version of each article, as relays would
+func latest(events []*nostr.Event) []*nostr.Event {
+	newest := make(map[string]*nostr.Event)
+	for _, evt := range events {
+		key := evt.PubKey + ":" + evt.Tags.GetD()
+		if current, ok := newest[key]; !ok || evt.CreatedAt > current.CreatedAt {
+			newest[key] = evt
+		}
+	}
+
+	result := make([]*nostr.Event, 0, len(newest))
+	for _, evt := range newest {
+		result = append(result, evt)
+	}
+
+	return result
+}
@@ -0,0 +1,90 @@
+package linkcheck
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// DanglingLink is a wikilink target for which no article exists
+type DanglingLink struct {
+	Target string
+	Count  int
+}
+
+// ImporterReport summarizes the wikilinks of all articles of one author
+type ImporterReport struct {
+	PubKey   string
+	Articles int
+	Links    int
+	Targets  int
+	Dangling []DanglingLink
+}
+
+func (r ImporterReport) DanglingCount() int {
+	total := 0
+	for _, d := range r.Dangling {
+		total += d.Count
+	}
+
+	return total
+}
+
+// check builds one report per author. A link resolves when any of the given
+// events (articles or redirects) has the normalized target as its "d" tag.
+func check(events []*nostr.Event) []ImporterReport {
+	known := make(map[string]struct{})
+	for _, evt := range events {
+		if d := evt.Tags.GetD(); d != "" {
+			known[d] = struct{}{}
+		}
+	}
+
+	reports := make(map[string]*ImporterReport)
+	targets := make(map[string]map[string]int)
+	for _, evt := range events {
+		report, ok := reports[evt.PubKey]
+		if !ok {
+			report = &ImporterReport{PubKey: evt.PubKey}
+			reports[evt.PubKey] = report
+			targets[evt.PubKey] = make(map[string]int)
+		}
+
+		if evt.Kind != 30818 {
+			continue
+		}
+
+		report.Articles++
+		for _, target := range extractWikilinks(evt.Content) {
+			report.Links++
+			targets[evt.PubKey][target]++
+		}
+	}
+
+	result := make([]ImporterReport, 0, len(reports))
+	for pubkey, report := range reports {
+		report.Targets = len(targets[pubkey])
+
+		for target, count := range targets[pubkey] {
+			if _, ok := known[target]; !ok {
+				report.Dangling = append(report.Dangling, DanglingLink{Target: target, Count: count})
+			}
+		}
+
+		slices.SortFunc(report.Dangling, func(a, b DanglingLink) int {
+			if a.Count != b.Count {
+				return b.Count - a.Count
+			}
+			return cmp.Compare(a.Target, b.Target)
+		})
+
+		result = append(result, *report)
+	}
+
+	slices.SortFunc(result, func(a, b ImporterReport) int {
+		return cmp.Compare(a.PubKey, b.PubKey)
+	})
+
+	return result
+}
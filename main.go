@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 
+	"fiatjaf/wiki-importer/linkcheck"
 	"fiatjaf/wiki-importer/mediawiki"
 	"fiatjaf/wiki-importer/movies"
 	"fiatjaf/wiki-importer/names"
@@ -23,6 +24,26 @@ func main() {
 		Value:   0, // default value
 	}
 
+	exportFileFlag := &cli.StringFlag{
+		Name:  "export-file",
+		Usage: "Read a downloaded TMDB daily export (.json.gz) instead of fetching it",
+	}
+
+	exportDateFlag := &cli.StringFlag{
+		Name:  "export-date",
+		Usage: "Date (YYYY-MM-DD) of the TMDB daily export to fetch, defaults to yesterday",
+	}
+
+	languagesFlag := &cli.StringSliceFlag{
+		Name:  "languages",
+		Usage: "Publish movies in these languages (e.g. en,pt-BR,de), the first one keeping the usual identifiers",
+	}
+
+	templatesFlag := &cli.StringFlag{
+		Name:  "templates",
+		Usage: "Directory with templates to use instead of the built-in ones (tmdb.adoc, omdb.adoc, merged.adoc, person.adoc, tv.adoc, season.adoc)",
+	}
+
 	cmd := &cli.Command{
 		Name:  "wiki-importer",
 		Usage: "Import data from various sources and publish to Nostr as NIP-54 Wiki content",
@@ -44,6 +65,19 @@ func main() {
 						Usage: "Import albums from progarchives",
 						Flags: []cli.Flag{
 							continueFlag,
+							&cli.BoolFlag{
+								Name:  "reviews",
+								Usage: "Also publish an article quoting each album's reviews",
+							},
+							&cli.UintFlag{
+								Name:  "max-reviews",
+								Usage: "How many reviews to quote for each album, 0 for all of them",
+								Value: 10,
+							},
+							&cli.UintFlag{
+								Name:  "end",
+								Usage: "Last album ID to import, found on the site when not given",
+							},
 						},
 						Action: handleProgArchivesAlbums,
 					},
@@ -52,9 +86,21 @@ func main() {
 						Usage: "Import artists from progarchives",
 						Flags: []cli.Flag{
 							continueFlag,
+							&cli.UintFlag{
+								Name:  "end",
+								Usage: "Last artist ID to import, found on the site when not given",
+							},
 						},
 						Action: handleProgArchivesArtists,
 					},
+					{
+						Name:  "genres",
+						Usage: "Import subgenre definitions and their artists from progarchives",
+						Flags: []cli.Flag{
+							continueFlag,
+						},
+						Action: handleProgArchivesGenres,
+					},
 				},
 			},
 			{
@@ -62,6 +108,19 @@ func main() {
 				Usage: "Import data from TMDB and OMDB",
 				Flags: []cli.Flag{
 					continueFlag,
+					exportFileFlag,
+					exportDateFlag,
+					&cli.BoolFlag{
+						Name:  "merged",
+						Usage: "Publish a single article combining TMDB and OMDB under TMDB_NOSTR_KEY",
+					},
+					&cli.UintFlag{
+						Name:  "cast-limit",
+						Usage: "How many cast members to list in each movie, 0 for all of them",
+						Value: 20,
+					},
+					templatesFlag,
+					languagesFlag,
 				},
 				Action: handleMovies,
 				Commands: []*cli.Command{
@@ -70,14 +129,130 @@ func main() {
 						Usage: "Import persons from The Person DB",
 						Flags: []cli.Flag{
 							continueFlag,
+							exportFileFlag,
+							exportDateFlag,
+							templatesFlag,
 						},
 						Action: handlePersons,
 					},
+					{
+						Name:  "tv",
+						Usage: "Import TV series from TMDB",
+						Flags: []cli.Flag{
+							continueFlag,
+							exportFileFlag,
+							exportDateFlag,
+							&cli.BoolFlag{
+								Name:  "seasons",
+								Usage: "Also publish one article per season",
+							},
+							templatesFlag,
+						},
+						Action: handleTv,
+					},
+					{
+						Name:  "sync",
+						Usage: "Republish the movies and persons changed on TMDB since the last sync",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "since",
+								Usage: "Sync changes since this date (YYYY-MM-DD) instead of since the last sync",
+							},
+							&cli.BoolFlag{
+								Name:  "merged",
+								Usage: "Publish a single article combining TMDB and OMDB under TMDB_NOSTR_KEY",
+							},
+							&cli.UintFlag{
+								Name:  "cast-limit",
+								Usage: "How many cast members to list in each movie, 0 for all of them",
+								Value: 20,
+							},
+							&cli.BoolFlag{
+								Name:  "include-adult",
+								Usage: "Also publish adult movies and persons",
+							},
+							&cli.BoolFlag{
+								Name:  "include-video",
+								Usage: "Also publish direct-to-video releases",
+							},
+							templatesFlag,
+							languagesFlag,
+						},
+						Action: handleMoviesSync,
+					},
+					{
+						Name:  "render",
+						Usage: "Print the article for a single TMDB ID instead of publishing it",
+						Flags: []cli.Flag{
+							&cli.UintFlag{
+								Name:     "id",
+								Usage:    "TMDB ID of the movie, or of the person with --person",
+								Required: true,
+							},
+							&cli.BoolFlag{
+								Name:  "person",
+								Usage: "Render a person instead of a movie",
+							},
+							&cli.BoolFlag{
+								Name:  "merged",
+								Usage: "Render the article combining TMDB and OMDB",
+							},
+							&cli.UintFlag{
+								Name:  "cast-limit",
+								Usage: "How many cast members to list, 0 for all of them",
+								Value: 20,
+							},
+							templatesFlag,
+							languagesFlag,
+						},
+						Action: handleMoviesRender,
+					},
+					{
+						Name:  "collections",
+						Usage: "Import movie collections from TMDB",
+						Flags: []cli.Flag{
+							continueFlag,
+							exportFileFlag,
+							exportDateFlag,
+							templatesFlag,
+						},
+						Action: handleCollections,
+					},
+					{
+						Name:  "companies",
+						Usage: "Import production companies from TMDB with their most popular movies",
+						Flags: []cli.Flag{
+							continueFlag,
+							exportFileFlag,
+							exportDateFlag,
+							templatesFlag,
+							&cli.UintFlag{
+								Name:  "max-movies",
+								Usage: "How many of the most popular movies to list, 0 for all of them",
+								Value: 100,
+							},
+						},
+						Action: handleCompanies,
+					},
+					{
+						Name:  "genres",
+						Usage: "Import movie genres from TMDB with their most popular movies",
+						Flags: []cli.Flag{
+							continueFlag,
+							templatesFlag,
+							&cli.UintFlag{
+								Name:  "max-movies",
+								Usage: "How many of the most popular movies to list, 0 for all of them",
+								Value: 100,
+							},
+						},
+						Action: handleGenres,
+					},
 				},
 			},
 			{
 				Name:  "mediawiki",
-				Usage: "Import data from MediaWiki supported sites",
+				Usage: "Import data from MediaWiki supported sites (set MEDIAWIKI_USERNAME and MEDIAWIKI_PASSWORD or MEDIAWIKI_OAUTH_TOKEN for wikis that require login)",
 				Flags: []cli.Flag{
 					&cli.StringFlag{
 						Name:    "host",
@@ -91,9 +266,44 @@ func main() {
 						Usage:   "Continue from specific page",
 						Value:   "",
 					},
+					&cli.StringFlag{
+						Name:  "cookie-file",
+						Usage: "Where to keep the login session (defaults to cookies/<host>.json)",
+						Value: "",
+					},
+					&cli.StringSliceFlag{
+						Name:    "namespace",
+						Aliases: []string{"ns"},
+						Usage:   "Namespace to import, by name or number, optionally with an identifier prefix (e.g. Portal=portal-); defaults to the main namespace",
+					},
 				},
 				Action: handleMediaWiki,
 			},
+			{
+				Name:  "linkcheck",
+				Usage: "Report wikilinks that point to articles that don't exist",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "relay",
+						Usage: "Relays to fetch published articles from",
+					},
+					&cli.StringFlag{
+						Name:  "file",
+						Usage: "Read events from a JSON lines file (e.g. from `nak req`) instead of relays",
+					},
+					&cli.StringSliceFlag{
+						Name:    "author",
+						Aliases: []string{"a"},
+						Usage:   "Only check articles from these pubkeys (hex or npub)",
+					},
+					&cli.UintFlag{
+						Name:  "limit",
+						Usage: "How many dangling targets to list per importer (0 for all)",
+						Value: 20,
+					},
+				},
+				Action: handleLinkCheck,
+			},
 		},
 	}
 
@@ -144,6 +354,19 @@ func handleProgArchivesArtists(ctx context.Context, c *cli.Command) error {
 	return nil
 }
 
+func handleProgArchivesGenres(ctx context.Context, c *cli.Command) error {
+	logger, err := createLogger("progarchives-genres")
+	if err != nil {
+		return fmt.Errorf("create logger: %w", err)
+	}
+
+	if err := progarchives.HandleGenres(ctx, logger, c); err != nil {
+		return fmt.Errorf("handle genres: %w", err)
+	}
+
+	return nil
+}
+
 func handleMovies(ctx context.Context, c *cli.Command) error {
 	logger, err := createLogger("movies")
 	if err != nil {
@@ -170,6 +393,84 @@ func handlePersons(ctx context.Context, c *cli.Command) error {
 	return nil
 }
 
+func handleTv(ctx context.Context, c *cli.Command) error {
+	logger, err := createLogger("movies-tv")
+	if err != nil {
+		return fmt.Errorf("create logger: %w", err)
+	}
+
+	if err := movies.HandleTv(ctx, logger, c); err != nil {
+		return fmt.Errorf("handle tv: %w", err)
+	}
+
+	return nil
+}
+
+func handleMoviesSync(ctx context.Context, c *cli.Command) error {
+	logger, err := createLogger("movies-sync")
+	if err != nil {
+		return fmt.Errorf("create logger: %w", err)
+	}
+
+	if err := movies.HandleSync(ctx, logger, c); err != nil {
+		return fmt.Errorf("handle movies sync: %w", err)
+	}
+
+	return nil
+}
+
+func handleMoviesRender(ctx context.Context, c *cli.Command) error {
+	logger, err := createLogger("movies-render")
+	if err != nil {
+		return fmt.Errorf("create logger: %w", err)
+	}
+
+	if err := movies.HandleRender(ctx, logger, c); err != nil {
+		return fmt.Errorf("handle movies render: %w", err)
+	}
+
+	return nil
+}
+
+func handleCollections(ctx context.Context, c *cli.Command) error {
+	logger, err := createLogger("movies-collections")
+	if err != nil {
+		return fmt.Errorf("create logger: %w", err)
+	}
+
+	if err := movies.HandleCollections(ctx, logger, c); err != nil {
+		return fmt.Errorf("handle collections: %w", err)
+	}
+
+	return nil
+}
+
+func handleCompanies(ctx context.Context, c *cli.Command) error {
+	logger, err := createLogger("movies-companies")
+	if err != nil {
+		return fmt.Errorf("create logger: %w", err)
+	}
+
+	if err := movies.HandleCompanies(ctx, logger, c); err != nil {
+		return fmt.Errorf("handle companies: %w", err)
+	}
+
+	return nil
+}
+
+func handleGenres(ctx context.Context, c *cli.Command) error {
+	logger, err := createLogger("movies-genres")
+	if err != nil {
+		return fmt.Errorf("create logger: %w", err)
+	}
+
+	if err := movies.HandleGenres(ctx, logger, c); err != nil {
+		return fmt.Errorf("handle genres: %w", err)
+	}
+
+	return nil
+}
+
 func handleMediaWiki(ctx context.Context, c *cli.Command) error {
 	logger, err := createLogger("mediawiki")
 	if err != nil {
@@ -195,3 +496,16 @@ func handleNames(ctx context.Context, c *cli.Command) error {
 
 	return nil
 }
+
+func handleLinkCheck(ctx context.Context, c *cli.Command) error {
+	logger, err := createLogger("linkcheck")
+	if err != nil {
+		return fmt.Errorf("create logger: %w", err)
+	}
+
+	if err := linkcheck.HandleLinkCheck(ctx, logger, c); err != nil {
+		return fmt.Errorf("handle linkcheck: %w", err)
+	}
+
+	return nil
+}
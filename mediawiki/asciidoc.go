@@ -8,11 +8,10 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
-
-	"fiatjaf/wiki-importer/common"
 )
 
 type PageResult struct {
+	Error *apiError `json:"error"`
 	Parse struct {
 		Title    string `json:"title"`
 		Wikitext struct {
@@ -21,7 +20,7 @@ type PageResult struct {
 	} `json:"parse"`
 }
 
-func asciidoc(host string, pageTitle string) (string, string, error) {
+func asciidoc(client *Client, links *linkResolver, pageTitle string) (string, string, error) {
 	qs := url.Values{
 		"action": {"parse"},
 		"format": {"json"},
@@ -29,7 +28,7 @@ func asciidoc(host string, pageTitle string) (string, string, error) {
 		"page":   {pageTitle},
 	}
 
-	r, err := common.HttpGet(apiBase(host) + "?" + qs.Encode())
+	r, err := client.get(qs)
 	if err != nil {
 		return "", "", err
 	}
@@ -41,11 +40,22 @@ func asciidoc(host string, pageTitle string) (string, string, error) {
 	}
 	r.Body.Close()
 
+	if res.Error != nil {
+		return "", "", res.Error
+	}
+
 	asciidoc, err := parseWikitext(res, "mediawiki/lua")
 	if err != nil {
 		return "", "", err
 	}
 
+	if links != nil {
+		asciidoc, err = links.rewrite(asciidoc)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
 	return res.Parse.Title, asciidoc, nil
 }
 
@@ -60,6 +70,9 @@ func parseWikitext(res PageResult, luaFolder string) (string, error) {
 		`\u003E`, ">",
 	).Replace(content)
 
+	// Before filtering lines, code may very well start with "|"
+	content, blocks := extractSpecialBlocks(content)
+
 	// Pre-allocate capacity
 	wikitext.Grow(len(content))
 
@@ -91,5 +104,5 @@ func parseWikitext(res PageResult, luaFolder string) (string, error) {
 		return "", fmt.Errorf("pandoc error %w: %s", err, stderr.String())
 	}
 
-	return string(asciidoc), nil
+	return blocks.restore(string(asciidoc)), nil
 }
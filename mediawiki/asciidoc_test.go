@@ -126,6 +126,101 @@ func TestParseWikitext(t *testing.T) {
 			expected: "This Brotherhood has several Sections, as can be seen in one of the letters [[Master]] [[Tuitit Bey]] sent to [[H. S. Olcott]]:footnote:[Curuppumullage Jinarajadasa, _Letters from the Masters of the Wisdom_ Second Series, Letter No. 3 (Adyar, Madras: Theosophical Publishing House, 1977), 18. In 1926 edition, see page 21.]",
 			wantErr:  false,
 		},
+		{
+			name: "math",
+			input: PageResult{
+				Parse: struct {
+					Title    string `json:"title"`
+					Wikitext struct {
+						All string `json:"*"`
+					} `json:"wikitext"`
+				}{
+					Wikitext: struct {
+						All string `json:"*"`
+					}{
+						All: "The mass-energy equivalence <math>E = mc^2</math> is\n:<math>\\frac{a}{b}</math>\n",
+					},
+				},
+			},
+			expected: "The mass-energy equivalence stem:[E = mc^2] is\n\n[stem]\n++++\n\\frac{a}{b}\n++++",
+			wantErr:  false,
+		},
+		{
+			name: "syntaxhighlight",
+			input: PageResult{
+				Parse: struct {
+					Title    string `json:"title"`
+					Wikitext struct {
+						All string `json:"*"`
+					} `json:"wikitext"`
+				}{
+					Wikitext: struct {
+						All string `json:"*"`
+					}{
+						All: "Example:\n<syntaxhighlight lang=\"python\">\nfor x in y:\n    print(x)\n</syntaxhighlight>",
+					},
+				},
+			},
+			expected: "Example:\n\n[source,python]\n----\nfor x in y:\n    print(x)\n----",
+			wantErr:  false,
+		},
+		{
+			name: "pre",
+			input: PageResult{
+				Parse: struct {
+					Title    string `json:"title"`
+					Wikitext struct {
+						All string `json:"*"`
+					} `json:"wikitext"`
+				}{
+					Wikitext: struct {
+						All string `json:"*"`
+					}{
+						All: "<pre>\n| a | b |\n</pre>",
+					},
+				},
+			},
+			expected: "----\n| a | b |\n----",
+			wantErr:  false,
+		},
+		{
+			name: "poem",
+			input: PageResult{
+				Parse: struct {
+					Title    string `json:"title"`
+					Wikitext struct {
+						All string `json:"*"`
+					} `json:"wikitext"`
+				}{
+					Wikitext: struct {
+						All string `json:"*"`
+					}{
+						All: "<poem>\nFirst line\nsecond line\n</poem>",
+					},
+				},
+			},
+			expected: "[verse]\n____\nFirst line\nsecond line\n____",
+			wantErr:  false,
+		},
+		{
+			name: "blockquote",
+			input: PageResult{
+				Parse: struct {
+					Title    string `json:"title"`
+					Wikitext struct {
+						All string `json:"*"`
+					} `json:"wikitext"`
+				}{
+					Wikitext: struct {
+						All string `json:"*"`
+					}{
+						All: "<blockquote>Quoted text.</blockquote>",
+					},
+				},
+			},
+			expected: "____\n\nQuoted text.\n\n____",
+			wantErr:  false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,241 @@
+package mediawiki
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// Credentials for wikis that only allow reading to logged-in users. Either a
+// bot password (created at Special:BotPasswords) or the access token of an
+// owner-only OAuth 2 consumer can be used.
+type Credentials struct {
+	Username   string
+	Password   string
+	OAuthToken string
+}
+
+func (c Credentials) empty() bool {
+	return c.OAuthToken == "" && (c.Username == "" || c.Password == "")
+}
+
+type tokensResult struct {
+	Error *apiError `json:"error"`
+	Query struct {
+		Tokens struct {
+			LoginToken string `json:"logintoken"`
+		} `json:"tokens"`
+	} `json:"query"`
+}
+
+type loginResult struct {
+	Error *apiError `json:"error"`
+	Login struct {
+		Result string `json:"result"`
+		Reason string `json:"reason"`
+	} `json:"login"`
+}
+
+type userInfoResult struct {
+	Error *apiError `json:"error"`
+	Query struct {
+		UserInfo struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+		} `json:"userinfo"`
+	} `json:"query"`
+}
+
+type savedCookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Login authenticates the client. Session cookies are loaded from and saved
+// to cookieFile (if not empty) so a session survives between runs and we
+// don't have to log in again every time.
+func (c *Client) Login(creds Credentials, cookieFile string) error {
+	if creds.empty() {
+		return errors.New("username and password or an oauth token are required")
+	}
+
+	if creds.OAuthToken != "" {
+		c.oauthToken = creds.OAuthToken
+
+		if _, err := c.currentUser(); err != nil {
+			return fmt.Errorf("oauth: %w", err)
+		}
+
+		return nil
+	}
+
+	c.cookieFile = cookieFile
+	if err := c.loadCookies(); err != nil {
+		return fmt.Errorf("load cookies: %w", err)
+	}
+
+	// A saved session may still be valid
+	if name, err := c.currentUser(); err == nil && name != "" {
+		return nil
+	}
+
+	token, err := c.loginToken()
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.post(url.Values{
+		"action":     {"login"},
+		"format":     {"json"},
+		"lgname":     {creds.Username},
+		"lgpassword": {creds.Password},
+		"lgtoken":    {token},
+	})
+	if err != nil {
+		return fmt.Errorf("login request: %w", err)
+	}
+
+	var res loginResult
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		resp.Body.Close()
+		return fmt.Errorf("decode login result: %w", err)
+	}
+	resp.Body.Close()
+
+	if res.Error != nil {
+		return fmt.Errorf("login: %w", res.Error)
+	}
+
+	if res.Login.Result != "Success" {
+		return fmt.Errorf("login failed: %s %s", res.Login.Result, res.Login.Reason)
+	}
+
+	if err := c.saveCookies(); err != nil {
+		return fmt.Errorf("save cookies: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) loginToken() (string, error) {
+	resp, err := c.get(url.Values{
+		"action": {"query"},
+		"format": {"json"},
+		"meta":   {"tokens"},
+		"type":   {"login"},
+	})
+	if err != nil {
+		return "", fmt.Errorf("login token request: %w", err)
+	}
+
+	var res tokensResult
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		resp.Body.Close()
+		return "", fmt.Errorf("decode login token: %w", err)
+	}
+	resp.Body.Close()
+
+	if res.Error != nil {
+		return "", fmt.Errorf("login token: %w", res.Error)
+	}
+
+	if res.Query.Tokens.LoginToken == "" {
+		return "", errors.New("no login token returned")
+	}
+
+	return res.Query.Tokens.LoginToken, nil
+}
+
+// currentUser returns the name of the logged in user or an empty string for
+// anonymous sessions.
+func (c *Client) currentUser() (string, error) {
+	resp, err := c.get(url.Values{
+		"action": {"query"},
+		"format": {"json"},
+		"meta":   {"userinfo"},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var res userInfoResult
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		resp.Body.Close()
+		return "", err
+	}
+	resp.Body.Close()
+
+	if res.Error != nil {
+		return "", res.Error
+	}
+
+	if res.Query.UserInfo.ID == 0 {
+		return "", nil
+	}
+
+	return res.Query.UserInfo.Name, nil
+}
+
+func (c *Client) loadCookies() error {
+	if c.cookieFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.cookieFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var saved []savedCookie
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+
+	u, err := url.Parse(c.apiURL)
+	if err != nil {
+		return err
+	}
+
+	cookies := make([]*http.Cookie, 0, len(saved))
+	for _, s := range saved {
+		cookies = append(cookies, &http.Cookie{Name: s.Name, Value: s.Value})
+	}
+	c.httpClient.Jar.SetCookies(u, cookies)
+
+	return nil
+}
+
+func (c *Client) saveCookies() error {
+	if c.cookieFile == "" {
+		return nil
+	}
+
+	u, err := url.Parse(c.apiURL)
+	if err != nil {
+		return err
+	}
+
+	cookies := c.httpClient.Jar.Cookies(u)
+	saved := make([]savedCookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		saved = append(saved, savedCookie{Name: cookie.Name, Value: cookie.Value})
+	}
+
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.cookieFile), 0700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(c.cookieFile, data, 0600)
+}
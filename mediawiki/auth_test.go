@@ -0,0 +1,206 @@
+package mediawiki
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeWiki emulates the parts of a login-only MediaWiki api.php we use
+type fakeWiki struct {
+	logins int
+	// main namespace pages that prop=info finds, and how often it was asked
+	pages       []string
+	infoQueries int
+}
+
+func (f *fakeWiki) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	authorized := r.Header.Get("Authorization") == "Bearer secret-token"
+	if cookie, err := r.Cookie("wikiSession"); err == nil && cookie.Value == "logged-in" {
+		authorized = true
+	}
+
+	var res any
+	switch {
+	case r.Form.Get("action") == "login":
+		if r.Method != "POST" {
+			res = map[string]any{"error": map[string]string{"code": "mustpostparams", "info": "use POST"}}
+		} else if r.Form.Get("lgtoken") != "login-token" {
+			res = map[string]any{"login": map[string]string{"result": "WrongToken"}}
+		} else if r.Form.Get("lgname") != "Importer@bot" || r.Form.Get("lgpassword") != "hunter2" {
+			res = map[string]any{"login": map[string]string{"result": "Failed", "reason": "Incorrect username or password entered."}}
+		} else {
+			f.logins++
+			http.SetCookie(w, &http.Cookie{Name: "wikiSession", Value: "logged-in"})
+			res = map[string]any{"login": map[string]string{"result": "Success"}}
+		}
+	case r.Form.Get("meta") == "tokens":
+		res = map[string]any{"query": map[string]any{"tokens": map[string]string{"logintoken": "login-token"}}}
+	case r.Form.Get("meta") == "userinfo":
+		if authorized {
+			res = map[string]any{"query": map[string]any{"userinfo": map[string]any{"id": 7, "name": "Importer"}}}
+		} else {
+			res = map[string]any{"query": map[string]any{"userinfo": map[string]any{"id": 0, "name": "127.0.0.1", "anon": ""}}}
+		}
+	case !authorized:
+		res = map[string]any{"error": map[string]string{"code": "readapidenied", "info": "You need read permission to use this module."}}
+	case r.Form.Get("meta") == "siteinfo":
+		res = map[string]any{"query": map[string]any{
+			"general": map[string]string{"case": "first-letter"},
+			"namespaces": map[string]any{
+				"0":   map[string]any{"id": 0, "case": "first-letter", "*": ""},
+				"2":   map[string]any{"id": 2, "case": "first-letter", "canonical": "User", "*": "User"},
+				"4":   map[string]any{"id": 4, "case": "first-letter", "canonical": "Project", "*": "Handbook"},
+				"100": map[string]any{"id": 100, "case": "case-sensitive", "canonical": "Portal", "*": "Portal"},
+			},
+			"namespacealiases": []map[string]any{{"id": 4, "*": "HB"}},
+		}}
+	case r.Form.Get("redirects") != "":
+		redirects := []map[string]string{}
+		for _, title := range strings.Split(r.Form.Get("titles"), "|") {
+			switch title {
+			case "Duty":
+				redirects = append(redirects, map[string]string{"from": title, "to": "On-call"})
+			case "Pager":
+				redirects = append(redirects, map[string]string{"from": title, "to": "On-call", "tofragment": "Paging rotation"})
+			}
+		}
+		res = map[string]any{"query": map[string]any{"redirects": redirects}}
+	case r.Form.Get("prop") == "info":
+		f.infoQueries++
+		pages := map[string]any{}
+		for i, title := range strings.Split(r.Form.Get("titles"), "|") {
+			// first-letter case, like the siteinfo says
+			title = strings.ToUpper(title[:1]) + title[1:]
+			if slices.Contains(f.pages, title) {
+				pages[strconv.Itoa(i+1)] = map[string]any{"pageid": i + 1, "ns": 0, "title": title}
+			} else {
+				pages[strconv.Itoa(-i-1)] = map[string]any{"ns": 0, "title": title, "missing": ""}
+			}
+		}
+		res = map[string]any{"query": map[string]any{"pages": pages}}
+	case r.Form.Get("list") == "allpages":
+		res = map[string]any{"query": map[string]any{"allpages": []map[string]any{
+			{"pageid": 1, "title": "Internal Handbook"},
+			{"pageid": 2, "title": "On-call"},
+		}}}
+	default:
+		res = map[string]any{"error": map[string]string{"code": "badvalue", "info": "unexpected request"}}
+	}
+
+	json.NewEncoder(w).Encode(res)
+}
+
+func listAll(t *testing.T, client *Client) ([]string, error) {
+	t.Helper()
+
+	ch, err := getListChannel(client, 0, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var titles []string
+	for title := range ch {
+		titles = append(titles, title)
+	}
+
+	return titles, nil
+}
+
+func TestLogin(t *testing.T) {
+	wiki := &fakeWiki{}
+	server := httptest.NewServer(wiki)
+	defer server.Close()
+
+	cookieFile := filepath.Join(t.TempDir(), "cookies", "wiki.json")
+
+	tests := []struct {
+		name    string
+		creds   Credentials
+		logins  int
+		wantErr bool
+	}{
+		{
+			name:    "wrong password",
+			creds:   Credentials{Username: "Importer@bot", Password: "wrong"},
+			logins:  0,
+			wantErr: true,
+		},
+		{
+			name:    "bot password",
+			creds:   Credentials{Username: "Importer@bot", Password: "hunter2"},
+			logins:  1,
+			wantErr: false,
+		},
+		{
+			name:    "session reused from cookie file",
+			creds:   Credentials{Username: "Importer@bot", Password: "hunter2"},
+			logins:  1,
+			wantErr: false,
+		},
+		{
+			name:    "oauth token",
+			creds:   Credentials{OAuthToken: "secret-token"},
+			logins:  1,
+			wantErr: false,
+		},
+		{
+			name:    "no credentials",
+			creds:   Credentials{Username: "Importer@bot"},
+			logins:  1,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(server.URL + "/w/api.php")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = client.Login(tt.creds, cookieFile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Login() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if wiki.logins != tt.logins {
+				t.Errorf("logins = %d, want %d", wiki.logins, tt.logins)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			titles, err := listAll(t, client)
+			if err != nil {
+				t.Fatalf("getListChannel() error = %v", err)
+			}
+
+			if len(titles) != 2 || titles[0] != "Internal Handbook" || titles[1] != "On-call" {
+				t.Errorf("titles = %v", titles)
+			}
+		})
+	}
+}
+
+func TestAnonymousAccessDenied(t *testing.T) {
+	server := httptest.NewServer(&fakeWiki{})
+	defer server.Close()
+
+	client, err := NewClient(server.URL + "/w/api.php")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := asciidoc(client, nil, "Internal Handbook"); err == nil {
+		t.Error("asciidoc() expected readapidenied error")
+	}
+}
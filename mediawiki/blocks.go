@@ -0,0 +1,178 @@
+package mediawiki
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Tags whose content pandoc would mangle or drop are taken out of the
+// wikitext before conversion and replaced with placeholders, which are
+// swapped for the corresponding AsciiDoc after pandoc is done.
+
+var (
+	mathRe       = regexp.MustCompile(`(?is)<math(\s[^>]*)?>(.*?)</math>`)
+	codeRe       = regexp.MustCompile(`(?is)<(?:syntaxhighlight|source)(\s[^>]*)?>(.*?)</(?:syntaxhighlight|source)>`)
+	preRe        = regexp.MustCompile(`(?is)<pre(\s[^>]*)?>(.*?)</pre>`)
+	poemRe       = regexp.MustCompile(`(?is)<poem(\s[^>]*)?>(.*?)</poem>`)
+	quoteOpenRe  = regexp.MustCompile(`(?i)<blockquote(\s[^>]*)?>`)
+	quoteCloseRe = regexp.MustCompile(`(?i)</blockquote>`)
+
+	langAttrRe    = regexp.MustCompile(`(?i)\blang\s*=\s*["']?([\w+#.-]+)`)
+	displayAttrRe = regexp.MustCompile(`(?i)\bdisplay\s*=\s*["']?(\w+)`)
+	inlineAttrRe  = regexp.MustCompile(`(?i)(^|\s)inline(\s|=|$)`)
+
+	boldRe   = regexp.MustCompile(`'''(.+?)'''`)
+	italicRe = regexp.MustCompile(`''(.+?)''`)
+)
+
+const (
+	quoteOpen  = "WIKIIMPORTERQUOTEOPEN"
+	quoteClose = "WIKIIMPORTERQUOTECLOSE"
+)
+
+type specialBlocks struct {
+	// placeholder -> asciidoc
+	replacements map[string]string
+	hasMath      bool
+}
+
+func (b *specialBlocks) block(asciidoc string) string {
+	placeholder := fmt.Sprintf("WIKIIMPORTERBLOCK%dEND", len(b.replacements))
+	b.replacements[placeholder] = asciidoc
+
+	return "\n\n" + placeholder + "\n\n"
+}
+
+func (b *specialBlocks) inline(asciidoc string) string {
+	placeholder := fmt.Sprintf("WIKIIMPORTERINLINE%dEND", len(b.replacements))
+	b.replacements[placeholder] = asciidoc
+
+	return placeholder
+}
+
+// extractSpecialBlocks replaces math, code, poem and blockquote tags in the
+// wikitext with placeholders
+func extractSpecialBlocks(wikitext string) (string, *specialBlocks) {
+	blocks := &specialBlocks{replacements: make(map[string]string)}
+
+	// code first, it may contain anything that looks like the other tags
+	wikitext = codeRe.ReplaceAllStringFunc(wikitext, func(s string) string {
+		match := codeRe.FindStringSubmatch(s)
+		attrs, code := match[1], match[2]
+
+		if inlineAttrRe.MatchString(attrs) {
+			return blocks.inline("`+" + strings.TrimSpace(code) + "+`")
+		}
+
+		header := "[source]"
+		if lang := langAttrRe.FindStringSubmatch(attrs); lang != nil {
+			header = "[source," + strings.ToLower(lang[1]) + "]"
+		}
+
+		return blocks.block(header + "\n----\n" + strings.Trim(code, "\r\n") + "\n----")
+	})
+
+	wikitext = preRe.ReplaceAllStringFunc(wikitext, func(s string) string {
+		text := preRe.FindStringSubmatch(s)[2]
+
+		return blocks.block("----\n" + strings.Trim(html.UnescapeString(text), "\r\n") + "\n----")
+	})
+
+	wikitext = extractMath(wikitext, blocks)
+
+	wikitext = poemRe.ReplaceAllStringFunc(wikitext, func(s string) string {
+		text := strings.Trim(poemRe.FindStringSubmatch(s)[2], "\r\n")
+
+		return blocks.block("[verse]\n____\n" + inlineWikitext(text) + "\n____")
+	})
+
+	wikitext = quoteOpenRe.ReplaceAllString(wikitext, "\n\n"+quoteOpen+"\n\n")
+	wikitext = quoteCloseRe.ReplaceAllString(wikitext, "\n\n"+quoteClose+"\n\n")
+
+	return wikitext, blocks
+}
+
+// extractMath turns <math> into inline stem macros, or into stem blocks when
+// asked for with display="block" or when the formula is alone on its line
+// (usually indented with ":")
+func extractMath(wikitext string, blocks *specialBlocks) string {
+	result := strings.Builder{}
+
+	last := 0
+	for _, loc := range mathRe.FindAllStringSubmatchIndex(wikitext, -1) {
+		start, end := loc[0], loc[1]
+		attrs := ""
+		if loc[2] != -1 {
+			attrs = wikitext[loc[2]:loc[3]]
+		}
+		tex := strings.TrimSpace(wikitext[loc[4]:loc[5]])
+
+		lineStart := strings.LastIndexByte(wikitext[:start], '\n') + 1
+		lineEnd := len(wikitext)
+		if idx := strings.IndexByte(wikitext[end:], '\n'); idx != -1 {
+			lineEnd = end + idx
+		}
+
+		display := ""
+		if match := displayAttrRe.FindStringSubmatch(attrs); match != nil {
+			display = strings.ToLower(match[1])
+		}
+
+		alone := strings.Trim(wikitext[lineStart:start], " \t:") == "" &&
+			strings.TrimSpace(wikitext[end:lineEnd]) == ""
+
+		blocks.hasMath = true
+
+		if display == "block" || (alone && display != "inline") {
+			if alone {
+				// drop the indentation too, or pandoc makes a list out of it
+				start = max(lineStart, last)
+			}
+
+			result.WriteString(wikitext[last:start])
+			result.WriteString(blocks.block("[stem]\n++++\n" + tex + "\n++++"))
+		} else {
+			result.WriteString(wikitext[last:start])
+			result.WriteString(blocks.inline("stem:[" + strings.ReplaceAll(tex, "]", `\]`) + "]"))
+		}
+
+		last = end
+	}
+	result.WriteString(wikitext[last:])
+
+	return result.String()
+}
+
+// restore puts the converted blocks back in place of their placeholders
+func (b *specialBlocks) restore(asciidoc string) string {
+	pairs := make([]string, 0, len(b.replacements)*2+4)
+	for placeholder, replacement := range b.replacements {
+		pairs = append(pairs, placeholder, replacement)
+	}
+	pairs = append(pairs, quoteOpen, "____", quoteClose, "____")
+
+	replacer := strings.NewReplacer(pairs...)
+
+	// a poem may hold math or code placeholders of its own
+	for range 2 {
+		asciidoc = replacer.Replace(asciidoc)
+	}
+
+	if b.hasMath {
+		// stem defaults to AsciiMath, wikis use LaTeX
+		asciidoc = ":stem: latexmath\n\n" + asciidoc
+	}
+
+	return asciidoc
+}
+
+// inlineWikitext converts the bits of inline markup that are common in poems.
+// Wikilinks are already written the way we want them.
+func inlineWikitext(text string) string {
+	text = boldRe.ReplaceAllString(text, "*$1*")
+	text = italicRe.ReplaceAllString(text, "_${1}_")
+
+	return text
+}
@@ -0,0 +1,91 @@
+package mediawiki
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSpecialBlocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "inline math",
+			input:    "The energy is <math>E = mc^2</math> in joules.",
+			expected: ":stem: latexmath\n\nThe energy is stem:[E = mc^2] in joules.",
+		},
+		{
+			name:     "inline math with brackets",
+			input:    "an interval <math>[a, b]</math>",
+			expected: ":stem: latexmath\n\nan interval stem:[[a, b\\]]",
+		},
+		{
+			name:     "indented math is a block",
+			input:    "The integral\n:<math>\\int_0^1 x\\,dx = \\frac{1}{2}</math>\nconverges.",
+			expected: ":stem: latexmath\n\nThe integral\n\n\n[stem]\n++++\n\\int_0^1 x\\,dx = \\frac{1}{2}\n++++\n\n\nconverges.",
+		},
+		{
+			name:     "display block math",
+			input:    `where <math display="block">a^2 + b^2 = c^2</math> holds`,
+			expected: ":stem: latexmath\n\nwhere \n\n[stem]\n++++\na^2 + b^2 = c^2\n++++\n\n holds",
+		},
+		{
+			name:     "two formulas on one line",
+			input:    "<math>x</math> and <math>y</math>",
+			expected: ":stem: latexmath\n\nstem:[x] and stem:[y]",
+		},
+		{
+			name:     "syntaxhighlight",
+			input:    "Example:\n<syntaxhighlight lang=\"Python\">\ndef f(x):\n    return x[[0]]\n</syntaxhighlight>",
+			expected: "Example:\n\n\n[source,python]\n----\ndef f(x):\n    return x[[0]]\n----\n\n",
+		},
+		{
+			name:     "source with table-like lines",
+			input:    "<source lang=lua>\n| not a table\n{| neither\n</source>",
+			expected: "\n\n[source,lua]\n----\n| not a table\n{| neither\n----\n\n",
+		},
+		{
+			name:     "inline syntaxhighlight",
+			input:    `call <syntaxhighlight lang="go" inline>fmt.Println()</syntaxhighlight> here`,
+			expected: "call `+fmt.Println()+` here",
+		},
+		{
+			name:     "pre",
+			input:    "<pre>\nif a &lt; b then\n  ''not italic''\n</pre>",
+			expected: "\n\n----\nif a < b then\n  ''not italic''\n----\n\n",
+		},
+		{
+			name:     "poem",
+			input:    "<poem>\nThe woods are ''lovely'', dark and deep,\nBut I have [[promises]] to keep,\n\nAnd '''miles''' to go before I sleep.\n</poem>",
+			expected: "\n\n[verse]\n____\nThe woods are _lovely_, dark and deep,\nBut I have [[promises]] to keep,\n\nAnd *miles* to go before I sleep.\n____\n\n",
+		},
+		{
+			name:     "poem with math",
+			input:    "<poem>\nroses are <math>r</math>\n</poem>",
+			expected: ":stem: latexmath\n\n\n\n[verse]\n____\nroses are stem:[r]\n____\n\n",
+		},
+		{
+			name:     "blockquote",
+			input:    "He wrote:\n<blockquote>Quoted [[text]].</blockquote>",
+			expected: "He wrote:\n\n\n____\n\nQuoted [[text]].\n\n____\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// pandoc leaves the placeholders alone, so skip it here
+			wikitext, blocks := extractSpecialBlocks(tt.input)
+
+			if strings.Contains(wikitext, "<") && !strings.Contains(tt.input, "&lt;") {
+				t.Errorf("tags left in wikitext: %q", wikitext)
+			}
+
+			got := blocks.restore(wikitext)
+			if got != tt.expected {
+				t.Errorf("restore() = \n%q\n<<WANT>>\n%q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package mediawiki
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+)
+
+// Client performs requests against the api.php endpoint of a single
+// MediaWiki host, keeping session cookies between calls.
+type Client struct {
+	apiURL     string
+	httpClient *http.Client
+	oauthToken string
+	cookieFile string
+}
+
+type apiError struct {
+	Code string `json:"code"`
+	Info string `json:"info"`
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("api error %s: %s", e.Code, e.Info)
+}
+
+func NewClient(apiURL string) (*Client, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, fmt.Errorf("create cookie jar: %w", err)
+	}
+
+	return &Client{
+		apiURL:     apiURL,
+		httpClient: &http.Client{Jar: jar},
+	}, nil
+}
+
+func (c *Client) get(qs url.Values) (*http.Response, error) {
+	req, err := http.NewRequest("GET", c.apiURL+"?"+qs.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.do(req)
+}
+
+func (c *Client) post(form url.Values) (*http.Response, error) {
+	req, err := http.NewRequest("POST", c.apiURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return c.do(req)
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if c.oauthToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.oauthToken)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != 200 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,262 @@
+package mediawiki
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/nbd-wtf/go-nostr/nip54"
+)
+
+var wikilinkRe = regexp.MustCompile(`\[\[([^\[\]|]+)(\|[^\[\]]*)?\]\]`)
+
+type redirect struct {
+	To       string
+	Fragment string
+}
+
+type redirectsResult struct {
+	Error *apiError `json:"error"`
+	Query struct {
+		Normalized []struct {
+			From string `json:"from"`
+			To   string `json:"to"`
+		} `json:"normalized"`
+		Redirects []struct {
+			From       string `json:"from"`
+			To         string `json:"to"`
+			ToFragment string `json:"tofragment"`
+		} `json:"redirects"`
+	} `json:"query"`
+}
+
+// linkResolver rewrites wikilinks so they point to the identifier of the
+// article a reader would actually land on.
+type linkResolver struct {
+	client     *Client
+	site       *SiteInfo
+	namespaces *namespaceMapper
+	// keyed by normalized title, an empty redirect means the title is not one
+	redirects map[string]redirect
+}
+
+func newLinkResolver(client *Client, site *SiteInfo, namespaces *namespaceMapper) *linkResolver {
+	return &linkResolver{
+		client:     client,
+		site:       site,
+		namespaces: namespaces,
+		redirects:  make(map[string]redirect),
+	}
+}
+
+func (r *linkResolver) rewrite(content string) (string, error) {
+	var titles, pending []string
+	outsideListings(content, func(part string) string {
+		for _, match := range wikilinkRe.FindAllStringSubmatch(part, -1) {
+			page, _, _ := strings.Cut(match[1], "#")
+			if strings.TrimSpace(page) == "" {
+				continue
+			}
+
+			title := r.site.normalizeTitle(page)
+			titles = append(titles, title)
+			if _, ok := r.redirects[title]; !ok {
+				r.redirects[title] = redirect{}
+				pending = append(pending, title)
+			}
+		}
+
+		return part
+	})
+
+	for len(pending) > 0 {
+		batch := pending[:min(50, len(pending))]
+		pending = pending[len(batch):]
+
+		if err := r.lookupRedirects(batch); err != nil {
+			return "", err
+		}
+	}
+
+	// the pages links land on may need to move away from main namespace ones
+	for i, title := range titles {
+		if rd := r.redirects[title]; rd.To != "" {
+			titles[i] = rd.To
+		}
+	}
+	if err := r.namespaces.check(titles); err != nil {
+		return "", err
+	}
+
+	return rewriteWikilinks(content, r.resolve), nil
+}
+
+func (r *linkResolver) lookupRedirects(titles []string) error {
+	resp, err := r.client.get(url.Values{
+		"action":    {"query"},
+		"format":    {"json"},
+		"redirects": {"1"},
+		"titles":    {strings.Join(titles, "|")},
+	})
+	if err != nil {
+		return fmt.Errorf("redirects: %w", err)
+	}
+
+	var res redirectsResult
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		resp.Body.Close()
+		return fmt.Errorf("decode redirects: %w", err)
+	}
+	resp.Body.Close()
+
+	if res.Error != nil {
+		return fmt.Errorf("redirects: %w", res.Error)
+	}
+
+	// the wiki may still normalize our titles a bit differently
+	aliases := make(map[string]string, len(res.Query.Normalized))
+	for _, n := range res.Query.Normalized {
+		aliases[n.To] = n.From
+	}
+
+	for _, rd := range res.Query.Redirects {
+		from := rd.From
+		if alias, ok := aliases[from]; ok {
+			from = alias
+		}
+
+		r.redirects[from] = redirect{To: rd.To, Fragment: rd.ToFragment}
+	}
+
+	return nil
+}
+
+func (r *linkResolver) resolve(page string) (string, string) {
+	title := r.site.normalizeTitle(page)
+	fragment := ""
+	if rd := r.redirects[title]; rd.To != "" {
+		title, fragment = rd.To, rd.Fragment
+	}
+
+	return r.namespaces.target(title), fragment
+}
+
+// rewriteWikilinks points every [[target]] or [[target|label]] at the page
+// returned by resolve, keeping the text readers see. Section anchors are
+// turned into the ids asciidoctor generates for section titles, and links to
+// sections of the same page become cross references.
+func rewriteWikilinks(content string, resolve func(page string) (string, string)) string {
+	return outsideListings(content, func(part string) string {
+		return wikilinkRe.ReplaceAllStringFunc(part, func(link string) string {
+			return rewriteWikilink(link, resolve)
+		})
+	})
+}
+
+func rewriteWikilink(link string, resolve func(page string) (string, string)) string {
+	match := wikilinkRe.FindStringSubmatch(link)
+	target := match[1]
+	label := strings.TrimPrefix(match[2], "|")
+	if match[2] == "" {
+		label = target
+	}
+
+	page, anchor, _ := strings.Cut(target, "#")
+	page = strings.TrimSpace(page)
+	anchor = strings.TrimSpace(anchor)
+
+	if page == "" {
+		if anchor == "" {
+			return link
+		}
+
+		if match[2] == "" {
+			label = anchor
+		}
+
+		return "<<" + sectionID(anchor) + "," + label + ">>"
+	}
+
+	resolved, fragment := resolve(page)
+	if nip54.NormalizeIdentifier(resolved) == nip54.NormalizeIdentifier(page) {
+		// e.g. only the case differs, the identifier is the same
+		resolved = page
+	}
+
+	if anchor == "" {
+		anchor = fragment
+	}
+
+	newTarget := resolved
+	if anchor != "" {
+		newTarget += "#" + sectionID(anchor)
+	}
+
+	if newTarget == label {
+		return "[[" + newTarget + "]]"
+	}
+
+	return "[[" + newTarget + "|" + label + "]]"
+}
+
+// sectionID mimics the ids asciidoctor generates for sections with its
+// default idprefix and idseparator, e.g. "Early life" becomes "_early_life".
+func sectionID(title string) string {
+	id := strings.Builder{}
+	id.WriteByte('_')
+
+	separated := true
+	for _, r := range strings.ToLower(strings.ReplaceAll(title, "_", " ")) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			id.WriteRune(r)
+			separated = false
+		case r == ' ' || r == '-' || r == '.':
+			if !separated {
+				id.WriteByte('_')
+				separated = true
+			}
+		}
+	}
+
+	return strings.TrimRight(id.String(), "_")
+}
+
+// outsideListings applies fn to the parts of an asciidoc document that are
+// not inside listing, literal or passthrough blocks, where [[...]] is code.
+func outsideListings(content string, fn func(part string) string) string {
+	result := strings.Builder{}
+	outside := strings.Builder{}
+
+	delimiter := ""
+	for _, line := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimRight(line, "\r\n")
+
+		if delimiter != "" {
+			result.WriteString(line)
+			if trimmed == delimiter {
+				delimiter = ""
+			}
+
+			continue
+		}
+
+		if trimmed == "----" || trimmed == "...." || trimmed == "++++" {
+			result.WriteString(fn(outside.String()))
+			outside.Reset()
+
+			delimiter = trimmed
+			result.WriteString(line)
+
+			continue
+		}
+
+		outside.WriteString(line)
+	}
+	result.WriteString(fn(outside.String()))
+
+	return result.String()
+}
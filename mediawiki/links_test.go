@@ -0,0 +1,149 @@
+package mediawiki
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRewriteWikilinks(t *testing.T) {
+	redirects := map[string]redirect{
+		"HPB":        {To: "Helena Petrovna Blavatsky"},
+		"Adyar":      {To: "Adyar (campus)", Fragment: "History"},
+		"Blavatsky":  {To: "Helena Petrovna Blavatsky"},
+		"Theosophy":  {To: "theosophy"},
+		"Early life": {},
+	}
+
+	resolve := func(page string) (string, string) {
+		if rd := redirects[page]; rd.To != "" {
+			return rd.To, rd.Fragment
+		}
+		return page, ""
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "plain link without redirect",
+			input:    "on [[October 10]], 1893",
+			expected: "on [[October 10]], 1893",
+		},
+		{
+			name:     "redirect keeps the visible text",
+			input:    "written by [[HPB]] and [[Blavatsky|H. P. Blavatsky]]",
+			expected: "written by [[Helena Petrovna Blavatsky|HPB]] and [[Helena Petrovna Blavatsky|H. P. Blavatsky]]",
+		},
+		{
+			name:     "case only redirects are left alone",
+			input:    "[[Theosophy]]",
+			expected: "[[Theosophy]]",
+		},
+		{
+			name:     "section anchor",
+			input:    "[[Helena Petrovna Blavatsky#Early life and education|her youth]]",
+			expected: "[[Helena Petrovna Blavatsky#_early_life_and_education|her youth]]",
+		},
+		{
+			name:     "section anchor without label",
+			input:    "[[Helena Petrovna Blavatsky#Later years]]",
+			expected: "[[Helena Petrovna Blavatsky#_later_years|Helena Petrovna Blavatsky#Later years]]",
+		},
+		{
+			name:     "redirect to a section",
+			input:    "[[Adyar|the campus]]",
+			expected: "[[Adyar (campus)#_history|the campus]]",
+		},
+		{
+			name:     "same page section",
+			input:    "see [[#China tray phenomenon]] and [[#Early_life|above]]",
+			expected: "see <<_china_tray_phenomenon,China tray phenomenon>> and <<_early_life,above>>",
+		},
+		{
+			name:     "code blocks are left alone",
+			input:    "[[HPB]]\n\n[source,lua]\n----\nlocal t = x[[HPB]]\n----\n\n[[HPB]]",
+			expected: "[[Helena Petrovna Blavatsky|HPB]]\n\n[source,lua]\n----\nlocal t = x[[HPB]]\n----\n\n[[Helena Petrovna Blavatsky|HPB]]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rewriteWikilinks(tt.input, resolve)
+			if got != tt.expected {
+				t.Errorf("rewriteWikilinks() = \n%v\n<<WANT>>\n%v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizeTitle(t *testing.T) {
+	server := httptest.NewServer(&fakeWiki{})
+	defer server.Close()
+
+	client, err := NewClient(server.URL + "/w/api.php")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.oauthToken = "secret-token"
+
+	site, err := getSiteInfo(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"on-call", "On-call"},
+		{"internal_handbook", "Internal handbook"},
+		{"  élan   vital ", "Élan vital"},
+		{"user:jane_doe", "User:Jane doe"},
+		{"project:rules", "Handbook:Rules"},
+		{"HB:rules", "Handbook:Rules"},
+		{"portal:science", "Portal:science"},
+		{":Portal:science", "Portal:science"},
+		{"Unknown:thing", "Unknown:thing"},
+	}
+
+	for _, tt := range tests {
+		if got := site.normalizeTitle(tt.input); got != tt.expected {
+			t.Errorf("normalizeTitle(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestLinkResolver(t *testing.T) {
+	server := httptest.NewServer(&fakeWiki{})
+	defer server.Close()
+
+	client, err := NewClient(server.URL + "/w/api.php")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.oauthToken = "secret-token"
+
+	site, err := getSiteInfo(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	namespaces, err := newNamespaceMapper(site, []string{"0", "Handbook=hb-"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	links := newLinkResolver(client, site, namespaces)
+
+	got, err := links.rewrite("Ask [[duty|whoever is on duty]], see [[pager]] or the [[internal_handbook]] and [[Project:Rules]].")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Ask [[On-call|whoever is on duty]], see [[On-call#_paging_rotation|pager]] or the [[internal_handbook]] and [[hb-Rules|Project:Rules]]."
+	if got != expected {
+		t.Errorf("rewrite() = \n%v\n<<WANT>>\n%v", got, expected)
+	}
+}
@@ -3,11 +3,11 @@ package mediawiki
 import (
 	"encoding/json"
 	"net/url"
-
-	"fiatjaf/wiki-importer/common"
+	"strconv"
 )
 
 type ListResult struct {
+	Error    *apiError `json:"error"`
 	Continue struct {
 		ApContinue string `json:"apcontinue"`
 	} `json:"continue"`
@@ -19,7 +19,44 @@ type ListResult struct {
 	} `json:"query"`
 }
 
-func getListChannel(host string, apcontinue string) (chan string, error) {
+// listPage gets one batch of the titles in a namespace and where the next
+// one starts, "" after the last
+func listPage(client *Client, namespace int, apcontinue string) ([]string, string, error) {
+	qs := url.Values{
+		"action":      {"query"},
+		"format":      {"json"},
+		"list":        {"allpages"},
+		"apnamespace": {strconv.Itoa(namespace)},
+	}
+
+	if apcontinue != "" {
+		qs.Set("apcontinue", apcontinue)
+	}
+
+	r, err := client.get(qs)
+	if err != nil {
+		return nil, "", err
+	}
+	defer r.Body.Close()
+
+	var res ListResult
+	if err := json.NewDecoder(r.Body).Decode(&res); err != nil {
+		return nil, "", err
+	}
+
+	if res.Error != nil {
+		return nil, "", res.Error
+	}
+
+	titles := make([]string, len(res.Query.AllPages))
+	for i, page := range res.Query.AllPages {
+		titles[i] = page.Title
+	}
+
+	return titles, res.Continue.ApContinue, nil
+}
+
+func getListChannel(client *Client, namespace int, apcontinue string) (chan string, error) {
 	ch := make(chan string)
 	errCh := make(chan error, 1) // buffered channel for errors
 
@@ -28,42 +65,23 @@ func getListChannel(host string, apcontinue string) (chan string, error) {
 		defer close(errCh)
 
 		for {
-			qs := url.Values{
-				"action": {"query"},
-				"format": {"json"},
-				"list":   {"allpages"},
-			}
-
-			if apcontinue != "" {
-				qs.Set("apcontinue", apcontinue)
-			}
-
-			r, err := common.HttpGet(apiBase(host) + "?" + qs.Encode())
+			titles, next, err := listPage(client, namespace, apcontinue)
 			if err != nil {
 				errCh <- err
 
 				return
 			}
 
-			var res ListResult
-			if err := json.NewDecoder(r.Body).Decode(&res); err != nil {
-				r.Body.Close()
-				errCh <- err
-
-				return
-			}
-			r.Body.Close()
-
-			for _, page := range res.Query.AllPages {
-				ch <- page.Title
+			for _, title := range titles {
+				ch <- title
 			}
 
-			if res.Continue.ApContinue == "" {
+			if next == "" {
 				// No more pages to fetch
 				return
 			}
 
-			apcontinue = res.Continue.ApContinue
+			apcontinue = next
 		}
 	}()
 
@@ -10,6 +10,13 @@ local function rawtext(content_parts)
   return raw
 end
 
+-- Targets may come percent-encoded, which would hide "#Section" anchors
+local function unescape(target)
+  return (target:gsub("%%(%x%x)", function(hex)
+    return string.char(tonumber(hex, 16))
+  end))
+end
+
 return {
   {
     Image = function(el)
@@ -18,7 +25,8 @@ return {
     Link = function(el)
       -- Always treat as wikilink unless it's an explicit http(s) URL
       if not el.target:match("^https?://") then
-        local target = el.target:gsub("_", " ")
+        -- keep "#Section" anchors, they are turned into asciidoc ids later
+        local target = unescape(el.target):gsub("_", " ")
         local raw = rawtext(el.content)
         
         if target:lower() == raw:lower() then
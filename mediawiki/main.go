@@ -4,13 +4,14 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"fiatjaf/wiki-importer/common"
 
 	"github.com/nbd-wtf/go-nostr"
-	"github.com/nbd-wtf/go-nostr/nip54"
 	"github.com/urfave/cli/v3"
 )
 
@@ -22,10 +23,30 @@ func HandleMediaWiki(ctx context.Context, logger *log.Logger, c *cli.Command) er
 		return fmt.Errorf("host is required")
 	}
 
-	return runWiki(ctx, logger, apcontinue, host)
+	cookieFile := c.String("cookie-file")
+	if cookieFile == "" {
+		cookieFile = filepath.Join("cookies", host+".json")
+	}
+
+	return runWiki(ctx, logger, WikiParams{
+		Host:       host,
+		Continue:   apcontinue,
+		CookieFile: cookieFile,
+		Namespaces: c.StringSlice("namespace"),
+	})
+}
+
+type WikiParams struct {
+	Host       string
+	Continue   string   // page title to continue from, in the first namespace
+	CookieFile string   // where login sessions are kept
+	Namespaces []string // namespaces to import, see newNamespaceMapper
 }
 
-func runWiki(ctx context.Context, logger *log.Logger, apcontinue, host string) error {
+func runWiki(ctx context.Context, logger *log.Logger, params WikiParams) error {
+	host := params.Host
+	apcontinue := params.Continue
+
 	relayURL, err := common.GetRequiredEnv("RELAY")
 	if err != nil {
 		return err
@@ -51,17 +72,88 @@ func runWiki(ctx context.Context, logger *log.Logger, apcontinue, host string) e
 		return err
 	}
 
-	ch, err := getListChannel(host, apcontinue)
+	client, err := NewClient(apiBase(host))
 	if err != nil {
 		return err
 	}
 
+	// Private wikis need a login, public ones are read anonymously
+	creds := Credentials{
+		Username:   os.Getenv("MEDIAWIKI_USERNAME"),
+		Password:   os.Getenv("MEDIAWIKI_PASSWORD"),
+		OAuthToken: os.Getenv("MEDIAWIKI_OAUTH_TOKEN"),
+	}
+	if !creds.empty() {
+		if err := client.Login(creds, params.CookieFile); err != nil {
+			return fmt.Errorf("[%s] login: %w", host, err)
+		}
+
+		logger.Printf("[%s] logged in\n", host)
+	}
+
+	site, err := getSiteInfo(client)
+	if err != nil {
+		return fmt.Errorf("[%s] siteinfo: %w", host, err)
+	}
+
+	namespaces, err := newNamespaceMapper(site, params.Namespaces)
+	if err != nil {
+		return fmt.Errorf("[%s] %w", host, err)
+	}
+
+	if namespaces.prefixed() {
+		namespaces.client = client
+	}
+
+	links := newLinkResolver(client, site, namespaces)
+
+	for i, namespace := range namespaces.ids {
+		if i > 0 {
+			apcontinue = ""
+		}
+
+		logger.Printf("[%s] importing namespace %d\n", host, namespace)
+
+		ch, err := getListChannel(client, namespace, apcontinue)
+		if err != nil {
+			return err
+		}
+
+		importPages(ctx, logger, ch, pageParams{
+			client:     client,
+			links:      links,
+			namespaces: namespaces,
+			pool:       pool,
+			nostrKey:   nostrKey,
+			relayURL:   relayURL,
+		})
+	}
+
+	return nil
+}
+
+type pageParams struct {
+	client     *Client
+	links      *linkResolver
+	namespaces *namespaceMapper
+	pool       *nostr.SimplePool
+	nostrKey   string
+	relayURL   string
+}
+
+func importPages(ctx context.Context, logger *log.Logger, ch chan string, params pageParams) {
+	client := params.client
+	links := params.links
+	pool := params.pool
+	nostrKey := params.nostrKey
+	relayURL := params.relayURL
+
 	for pageTitle := range ch {
 		pageTitle = strings.TrimSpace(pageTitle)
 
 		logger.Println(pageTitle)
 
-		title, asciiDoc, err := asciidoc(host, pageTitle)
+		title, asciiDoc, err := asciidoc(client, links, pageTitle)
 		if err != nil {
 			logger.Println(err, "\n=========\n-")
 
@@ -72,12 +164,18 @@ func runWiki(ctx context.Context, logger *log.Logger, apcontinue, host string) e
 
 		title = strings.TrimSpace(title)
 
+		if err := params.namespaces.check([]string{title}); err != nil {
+			logger.Println(err)
+
+			continue
+		}
+
 		evt := nostr.Event{
 			CreatedAt: nostr.Now(),
 			Kind:      30818,
 			Tags: nostr.Tags{
 				{"title", title},
-				{"d", nip54.NormalizeIdentifier(title)},
+				{"d", params.namespaces.identifier(title)},
 			},
 			Content: asciiDoc,
 		}
@@ -95,7 +193,16 @@ func runWiki(ctx context.Context, logger *log.Logger, apcontinue, host string) e
 			}
 
 			targetText := strings.Split(parts[1], "]]")[0]
-			target := nip54.NormalizeIdentifier(strings.TrimSpace(targetText))
+			targetText, _, _ = strings.Cut(targetText, "|")
+			targetText, _, _ = strings.Cut(targetText, "#")
+			targetText = strings.TrimSpace(targetText)
+
+			if err := params.namespaces.check([]string{targetText}); err != nil {
+				logger.Println(err)
+
+				continue
+			}
+			target := params.namespaces.identifier(targetText)
 
 			// If they normalize to the same identifier, skip
 			if target == currentId {
@@ -127,6 +234,4 @@ func runWiki(ctx context.Context, logger *log.Logger, apcontinue, host string) e
 
 		time.Sleep(2 * time.Second)
 	}
-
-	return nil
 }
@@ -0,0 +1,244 @@
+package mediawiki
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nbd-wtf/go-nostr/nip54"
+)
+
+// namespaceMapper decides which namespaces are imported and gives pages
+// outside the main namespace a prefixed identifier, so "Help:Contents" and
+// "Contents" never end up with the same "d" tag. A prefixed identifier can
+// still be what a main namespace title like "Help Contents" normalizes to,
+// those get the namespace ID appended once check finds that page.
+type namespaceMapper struct {
+	site *SiteInfo
+	// imported namespaces, in the order given, and their identifier prefixes
+	ids      []int
+	prefixes map[int]string
+	// where check asks about main namespace pages, nil not to ask
+	client *Client
+	// whether an identifier belongs to a main namespace page, for the ones
+	// check has asked about
+	taken map[string]bool
+}
+
+// newNamespaceMapper parses specs like "0", "Portal", "Help=help-" or
+// "100=portal-". Only the main namespace is imported when none are given.
+func newNamespaceMapper(site *SiteInfo, specs []string) (*namespaceMapper, error) {
+	if len(specs) == 0 {
+		specs = []string{"0"}
+	}
+
+	m := &namespaceMapper{
+		site:     site,
+		prefixes: make(map[int]string),
+		taken:    make(map[string]bool),
+	}
+
+	for _, spec := range specs {
+		name, prefix, hasPrefix := strings.Cut(spec, "=")
+		name = strings.TrimSpace(name)
+
+		id, err := strconv.Atoi(name)
+		if err != nil {
+			var ok bool
+			if id, ok = site.prefixes[strings.ToLower(name)]; !ok {
+				if !strings.EqualFold(name, "main") {
+					return nil, fmt.Errorf("unknown namespace %s", name)
+				}
+				id = 0
+			}
+		}
+
+		ns, ok := site.Namespaces[id]
+		if !ok {
+			return nil, fmt.Errorf("unknown namespace %d", id)
+		}
+
+		if slices.Contains(m.ids, id) {
+			return nil, fmt.Errorf("namespace %d given twice", id)
+		}
+
+		if !hasPrefix && id != 0 {
+			prefix = nip54.NormalizeIdentifier(ns.Name) + "-"
+		}
+		prefix = nip54.NormalizeIdentifier(prefix)
+
+		if id != 0 && prefix == "" {
+			return nil, fmt.Errorf("namespace %s needs a prefix", ns.Name)
+		}
+
+		for other, otherPrefix := range m.prefixes {
+			if prefix == otherPrefix {
+				return nil, fmt.Errorf("namespaces %d and %d have the same prefix %q", other, id, prefix)
+			}
+		}
+
+		m.ids = append(m.ids, id)
+		m.prefixes[id] = prefix
+	}
+
+	return m, nil
+}
+
+// prefixed is whether any imported namespace gets prefixed identifiers,
+// which could then collide with main namespace titles
+func (m *namespaceMapper) prefixed() bool {
+	for _, prefix := range m.prefixes {
+		if prefix != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// check asks the wiki whether main namespace pages have the identifiers
+// these titles would get, for the ones it didn't ask about before, so that
+// target can move away from them. Titles in the main namespace or in ones
+// without a prefix are never asked about.
+func (m *namespaceMapper) check(titles []string) error {
+	if m.client == nil {
+		return nil
+	}
+
+	// appending the namespace ID may hit another page, which needs asking
+	// about in turn
+	for {
+		pending := make(map[string]bool)
+		var candidates []string
+		for _, title := range titles {
+			target, checked := m.resolve(title)
+			identifier := nip54.NormalizeIdentifier(target)
+			if checked || pending[identifier] {
+				continue
+			}
+
+			pending[identifier] = true
+			candidates = append(candidates, mainCandidates(target)...)
+		}
+
+		if len(pending) == 0 {
+			return nil
+		}
+
+		existing, err := existingTitles(m.client, candidates)
+		if err != nil {
+			return fmt.Errorf("check main namespace titles: %w", err)
+		}
+
+		for identifier := range pending {
+			m.taken[identifier] = false
+		}
+		for _, title := range existing {
+			if identifier := nip54.NormalizeIdentifier(title); pending[identifier] {
+				m.taken[identifier] = true
+			}
+		}
+	}
+}
+
+// target is the wikilink target for a page title, with the namespace name
+// replaced by the identifier prefix for imported namespaces.
+func (m *namespaceMapper) target(title string) string {
+	target, _ := m.resolve(title)
+	return target
+}
+
+// resolve is target along with whether check has asked about it already
+func (m *namespaceMapper) resolve(title string) (string, bool) {
+	id, name := m.site.splitNamespace(title)
+
+	prefix, ok := m.prefixes[id]
+	if !ok || prefix == "" {
+		return title, true
+	}
+
+	target := prefix + name
+	for {
+		taken, checked := m.taken[nip54.NormalizeIdentifier(target)]
+		if !checked || !taken {
+			return target, checked
+		}
+
+		target += "-" + strconv.Itoa(id)
+	}
+}
+
+// mainCandidates are the main namespace titles that normalize like a
+// prefixed target, as far as they can be guessed: "portal-Science" is
+// "Portal Science" or "Portal-Science", in that case or in lower case
+func mainCandidates(target string) []string {
+	spaced := strings.ReplaceAll(target, "-", " ")
+
+	var candidates []string
+	for _, candidate := range []string{spaced, target, strings.ToLower(spaced), strings.ToLower(target)} {
+		if !slices.Contains(candidates, candidate) {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	return candidates
+}
+
+type infoResult struct {
+	Error *apiError `json:"error"`
+	Query struct {
+		Pages map[string]struct {
+			Title string `json:"title"`
+			// set, to "", for titles without a page
+			Missing *string `json:"missing"`
+			Invalid *string `json:"invalid"`
+		} `json:"pages"`
+	} `json:"query"`
+}
+
+// existingTitles asks which of some titles have a page, 50 at a time
+func existingTitles(client *Client, titles []string) ([]string, error) {
+	var existing []string
+
+	for len(titles) > 0 {
+		batch := titles[:min(50, len(titles))]
+		titles = titles[len(batch):]
+
+		resp, err := client.get(url.Values{
+			"action": {"query"},
+			"format": {"json"},
+			"prop":   {"info"},
+			"titles": {strings.Join(batch, "|")},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var res infoResult
+		err = json.NewDecoder(resp.Body).Decode(&res)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decode page info: %w", err)
+		}
+
+		if res.Error != nil {
+			return nil, res.Error
+		}
+
+		for _, page := range res.Query.Pages {
+			if page.Missing == nil && page.Invalid == nil {
+				existing = append(existing, page.Title)
+			}
+		}
+	}
+
+	return existing, nil
+}
+
+// identifier is the "d" tag for a page title
+func (m *namespaceMapper) identifier(title string) string {
+	return nip54.NormalizeIdentifier(m.target(title))
+}
@@ -0,0 +1,154 @@
+package mediawiki
+
+import (
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestNamespaceMapper(t *testing.T) {
+	wiki := &fakeWiki{}
+	server := httptest.NewServer(wiki)
+	defer server.Close()
+
+	client, err := NewClient(server.URL + "/w/api.php")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.oauthToken = "secret-token"
+
+	site, err := getSiteInfo(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		specs       []string
+		mainTitles  []string
+		ids         []int
+		identifiers map[string]string
+		// prop=info requests made to check the titles
+		infoQueries int
+		wantErr     bool
+	}{
+		{
+			name:  "main namespace by default",
+			specs: nil,
+			ids:   []int{0},
+			identifiers: map[string]string{
+				"On-call":        "on-call",
+				"Portal:Science": "portal-science",
+			},
+		},
+		{
+			name:  "prefix from the namespace name",
+			specs: []string{"main", "Portal", "HB"},
+			ids:   []int{0, 100, 4},
+			identifiers: map[string]string{
+				"On-call":          "on-call",
+				"Portal:Science":   "portal-science",
+				"Handbook:Rules":   "handbook-rules",
+				"Project:Rules":    "handbook-rules",
+				"User:Jane":        "user-jane",
+				"Handbook-Rules 2": "handbook-rules-2",
+			},
+		},
+		{
+			name:  "custom prefixes",
+			specs: []string{"0", "100=p-", "Handbook=hb"},
+			ids:   []int{0, 100, 4},
+			identifiers: map[string]string{
+				"Portal:Science": "p-science",
+				"Handbook:Rules": "hbrules",
+			},
+		},
+		{
+			name:       "main titles that normalize like prefixed ones",
+			specs:      []string{"main", "Portal"},
+			mainTitles: []string{"Portal Science", "Portal-Art 100", "Portal music", "On-call"},
+			ids:        []int{0, 100},
+			identifiers: map[string]string{
+				"Portal Science": "portal-science",
+				"Portal:Science": "portal-science-100",
+				"Portal:Art":     "portal-art",
+				"Portal:Music":   "portal-music-100",
+			},
+			infoQueries: 2,
+		},
+		{
+			name:       "appended namespace ID taken too",
+			specs:      []string{"main", "Portal"},
+			mainTitles: []string{"Portal Science", "Portal Science 100"},
+			ids:        []int{0, 100},
+			identifiers: map[string]string{
+				"Portal:Science": "portal-science-100-100",
+			},
+			infoQueries: 3,
+		},
+		{
+			name:    "unknown namespace",
+			specs:   []string{"Gallery"},
+			wantErr: true,
+		},
+		{
+			name:    "same namespace twice",
+			specs:   []string{"Portal", "100=p-"},
+			wantErr: true,
+		},
+		{
+			name:    "prefixes that would collide",
+			specs:   []string{"Portal=x-", "Handbook=x-"},
+			wantErr: true,
+		},
+		{
+			name:    "empty prefix outside the main namespace",
+			specs:   []string{"Portal="},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := newNamespaceMapper(site, tt.specs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newNamespaceMapper() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if tt.mainTitles != nil {
+				wiki.pages = tt.mainTitles
+				wiki.infoQueries = 0
+				m.client = client
+			}
+
+			if !slices.Equal(m.ids, tt.ids) {
+				t.Errorf("ids = %v, want %v", m.ids, tt.ids)
+			}
+
+			var titles []string
+			for title := range tt.identifiers {
+				titles = append(titles, title)
+			}
+			if err := m.check(titles); err != nil {
+				t.Fatal(err)
+			}
+			// asked once, the titles aren't asked about again
+			if err := m.check(titles); err != nil {
+				t.Fatal(err)
+			}
+			if wiki.infoQueries != tt.infoQueries {
+				t.Errorf("%d page info queries, want %d", wiki.infoQueries, tt.infoQueries)
+			}
+
+			for title, expected := range tt.identifiers {
+				if got := m.identifier(title); got != expected {
+					t.Errorf("identifier(%q) = %q, want %q", title, got, expected)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package mediawiki
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type namespace struct {
+	ID        int
+	Name      string
+	Canonical string
+	Case      string
+}
+
+// SiteInfo holds what we need from meta=siteinfo to normalize titles the
+// same way the wiki does.
+type SiteInfo struct {
+	Case       string
+	Namespaces map[int]namespace
+	// lowercased namespace names, canonical names and aliases
+	prefixes map[string]int
+}
+
+type siteInfoResult struct {
+	Error *apiError `json:"error"`
+	Query struct {
+		General struct {
+			Case string `json:"case"`
+		} `json:"general"`
+		Namespaces map[string]struct {
+			ID        int    `json:"id"`
+			Case      string `json:"case"`
+			Canonical string `json:"canonical"`
+			Name      string `json:"*"`
+		} `json:"namespaces"`
+		NamespaceAliases []struct {
+			ID    int    `json:"id"`
+			Alias string `json:"*"`
+		} `json:"namespacealiases"`
+	} `json:"query"`
+}
+
+func getSiteInfo(client *Client) (*SiteInfo, error) {
+	r, err := client.get(url.Values{
+		"action": {"query"},
+		"format": {"json"},
+		"meta":   {"siteinfo"},
+		"siprop": {"general|namespaces|namespacealiases"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var res siteInfoResult
+	if err := json.NewDecoder(r.Body).Decode(&res); err != nil {
+		r.Body.Close()
+		return nil, fmt.Errorf("decode siteinfo: %w", err)
+	}
+	r.Body.Close()
+
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	site := &SiteInfo{
+		Case:       res.Query.General.Case,
+		Namespaces: make(map[int]namespace, len(res.Query.Namespaces)),
+		prefixes:   make(map[string]int),
+	}
+
+	for key, ns := range res.Query.Namespaces {
+		id, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+
+		site.Namespaces[id] = namespace{
+			ID:        id,
+			Name:      ns.Name,
+			Canonical: ns.Canonical,
+			Case:      ns.Case,
+		}
+
+		if ns.Name != "" {
+			site.prefixes[strings.ToLower(ns.Name)] = id
+		}
+		if ns.Canonical != "" {
+			site.prefixes[strings.ToLower(ns.Canonical)] = id
+		}
+	}
+
+	for _, alias := range res.Query.NamespaceAliases {
+		site.prefixes[strings.ToLower(alias.Alias)] = alias.ID
+	}
+
+	return site, nil
+}
+
+// splitNamespace separates a known namespace prefix from a title, returning
+// namespace 0 when there is none.
+func (s *SiteInfo) splitNamespace(title string) (int, string) {
+	if idx := strings.Index(title, ":"); idx > 0 {
+		prefix := strings.ToLower(strings.TrimSpace(title[:idx]))
+		if id, ok := s.prefixes[prefix]; ok {
+			return id, strings.TrimSpace(title[idx+1:])
+		}
+	}
+
+	return 0, title
+}
+
+// normalizeTitle applies MediaWiki's title rules: underscores are spaces,
+// namespace prefixes use their local name and, on "first-letter" wikis, the
+// first letter of the page name is uppercase.
+func (s *SiteInfo) normalizeTitle(title string) string {
+	title = strings.Join(strings.Fields(strings.ReplaceAll(title, "_", " ")), " ")
+	title = strings.TrimPrefix(title, ":")
+
+	id, name := s.splitNamespace(title)
+
+	pageCase := s.Case
+	ns, ok := s.Namespaces[id]
+	if ok && ns.Case != "" {
+		pageCase = ns.Case
+	}
+
+	if pageCase == "first-letter" && name != "" {
+		first, size := utf8.DecodeRuneInString(name)
+		name = string(unicode.ToUpper(first)) + name[size:]
+	}
+
+	if id == 0 || !ok {
+		return name
+	}
+
+	return ns.Name + ":" + name
+}
@@ -0,0 +1,165 @@
+package movies
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// crew jobs as TMDB names them
+var (
+	directorJobs       = []string{"Director"}
+	composerJobs       = []string{"Original Music Composer", "Music"}
+	cinematographyJobs = []string{"Director of Photography"}
+	producerJobs       = []string{"Producer"}
+)
+
+func (m TMDBMovie) Directors() []string {
+	return m.crewNames(func(c TMDBCrewMember) bool { return slices.Contains(directorJobs, c.Job) })
+}
+
+func (m TMDBMovie) Writers() []string {
+	return m.crewNames(func(c TMDBCrewMember) bool { return c.Department == "Writing" })
+}
+
+func (m TMDBMovie) Composers() []string {
+	return m.crewNames(func(c TMDBCrewMember) bool { return slices.Contains(composerJobs, c.Job) })
+}
+
+func (m TMDBMovie) Cinematographers() []string {
+	return m.crewNames(func(c TMDBCrewMember) bool { return slices.Contains(cinematographyJobs, c.Job) })
+}
+
+func (m TMDBMovie) Producers() []string {
+	return m.crewNames(func(c TMDBCrewMember) bool { return slices.Contains(producerJobs, c.Job) })
+}
+
+// crewNames lists the crew members matching a condition once each, in the
+// order TMDB gives them
+func (m TMDBMovie) crewNames(match func(TMDBCrewMember) bool) []string {
+	var names []string
+	for _, member := range m.Crew {
+		if match(member) && !slices.Contains(names, member.Name) {
+			names = append(names, member.Name)
+		}
+	}
+
+	return names
+}
+
+// limitCast keeps the first castLimit members, or all of them when it's 0
+func (m *TMDBMovie) limitCast(castLimit uint64) {
+	if castLimit > 0 && uint64(len(m.Cast)) > castLimit {
+		m.Cast = m.Cast[0:castLimit]
+	}
+}
+
+type FilmographyDepartment struct {
+	Department string
+	Credits    []FilmographyCredit
+}
+
+type FilmographyCredit struct {
+	Title      string
+	Year       string
+	Identifier string
+	// characters played or jobs done, joined
+	Roles string
+	TV    bool
+}
+
+// filmography groups a person's credits by department, acting first, each
+// sorted by year. Movies and series link to the identifiers the movie and tv
+// importers gave them when they have seen them already.
+func filmography(person TMDBPerson, movies *identifierIndex, series *identifierIndex) []FilmographyDepartment {
+	type key struct {
+		department string
+		mediaType  string
+		id         int
+	}
+
+	credits := make(map[key]*FilmographyCredit)
+	roles := make(map[key][]string)
+	var order []key
+
+	add := func(department string, credit TMDBPersonCredit, role string) {
+		title := firstOf(credit.Title, credit.Name)
+		if title == "" || credit.Adult || credit.Video {
+			return
+		}
+
+		k := key{department, credit.MediaType, credit.ID}
+		if _, ok := credits[k]; !ok {
+			entry := &FilmographyCredit{
+				Title: title,
+				Year:  yearOf(firstOf(credit.ReleaseDate, credit.FirstAirDate)),
+				TV:    credit.MediaType == "tv",
+			}
+
+			if entry.TV {
+				entry.Identifier = indexedIdentifier(series, credit.ID, title)
+			} else {
+				entry.Identifier = indexedIdentifier(movies, credit.ID, title)
+			}
+
+			credits[k] = entry
+			order = append(order, k)
+		}
+
+		if role != "" && !slices.Contains(roles[k], role) {
+			roles[k] = append(roles[k], role)
+		}
+	}
+
+	for _, credit := range person.CombinedCredits.Cast {
+		add("Acting", credit, credit.Character)
+	}
+	for _, credit := range person.CombinedCredits.Crew {
+		add(firstOf(credit.Department, "Crew"), credit, credit.Job)
+	}
+
+	byDepartment := make(map[string]*FilmographyDepartment)
+	var departments []*FilmographyDepartment
+	for _, k := range order {
+		department, ok := byDepartment[k.department]
+		if !ok {
+			department = &FilmographyDepartment{Department: k.department}
+			byDepartment[k.department] = department
+			departments = append(departments, department)
+		}
+
+		credit := credits[k]
+		credit.Roles = strings.Join(roles[k], ", ")
+		department.Credits = append(department.Credits, *credit)
+	}
+
+	slices.SortStableFunc(departments, func(a, b *FilmographyDepartment) int {
+		if (a.Department == "Acting") != (b.Department == "Acting") {
+			if a.Department == "Acting" {
+				return -1
+			}
+			return 1
+		}
+
+		return cmp.Compare(a.Department, b.Department)
+	})
+
+	result := make([]FilmographyDepartment, len(departments))
+	for i, department := range departments {
+		// undated credits, usually announced projects, go last
+		slices.SortStableFunc(department.Credits, func(a, b FilmographyCredit) int {
+			if (a.Year == "") != (b.Year == "") {
+				if a.Year == "" {
+					return 1
+				}
+				return -1
+			}
+
+			return cmp.Compare(a.Year, b.Year)
+		})
+
+		result[i] = *department
+	}
+
+	return result
+}
@@ -0,0 +1,123 @@
+package movies
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCrew(t *testing.T) {
+	var movie TMDBMovie
+	if err := json.Unmarshal([]byte(`{"crew": [
+		{"name": "Laurence Olivier", "department": "Directing", "job": "Director"},
+		{"name": "Alan Dent", "department": "Writing", "job": "Screenplay"},
+		{"name": "Alan Dent", "department": "Writing", "job": "Adaptation"},
+		{"name": "William Walton", "department": "Sound", "job": "Original Music Composer"},
+		{"name": "Desmond Dickinson", "department": "Camera", "job": "Director of Photography"},
+		{"name": "Laurence Olivier", "department": "Production", "job": "Producer"},
+		{"name": "Someone", "department": "Production", "job": "Casting"}
+	]}`), &movie); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, got := range map[string][]string{
+		"directors":        movie.Directors(),
+		"writers":          movie.Writers(),
+		"composers":        movie.Composers(),
+		"cinematographers": movie.Cinematographers(),
+		"producers":        movie.Producers(),
+	} {
+		if len(got) != 1 {
+			t.Errorf("%s = %v, want exactly one", name, got)
+		}
+	}
+}
+
+func TestFilmography(t *testing.T) {
+	chdirTemp(t)
+
+	movies, err := loadIdentifierIndex("movies.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer movies.Close()
+	movies.assign(1, "Hamlet", "1948")
+	movies.assign(9, "Hamlet", "1996")
+
+	series, err := loadIdentifierIndex("tv.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer series.Close()
+	series.assign(3, "Brideshead Revisited", "1981")
+	series.assign(5, "Brideshead Revisited", "2027")
+
+	// through the fetch, which has to ask for the combined credits
+	serveTMDB(t, map[string]string{
+		"/person/3359?combined_credits,external_ids": `{"id": 3359, "name": "Laurence Olivier", "combined_credits": {
+		"cast": [
+			{"id": 1, "media_type": "movie", "title": "Hamlet", "release_date": "1948-05-04", "character": "Hamlet"},
+			{"id": 4, "media_type": "movie", "title": "Untitled Project", "character": "Himself"},
+			{"id": 3, "media_type": "tv", "name": "Brideshead Revisited", "first_air_date": "1981-10-12", "character": "Lord Marchmain"},
+			{"id": 2, "media_type": "movie", "title": "Rebecca", "release_date": "1940-03-27", "character": "Maxim"}
+		],
+		"crew": [
+			{"id": 1, "media_type": "movie", "title": "Hamlet", "release_date": "1948-05-04", "department": "Production", "job": "Producer"},
+			{"id": 1, "media_type": "movie", "title": "Hamlet", "release_date": "1948-05-04", "department": "Directing", "job": "Director"},
+			{"id": 1, "media_type": "movie", "title": "Hamlet", "release_date": "1948-05-04", "department": "Production", "job": "Executive Producer"}
+		]
+	}}`,
+	})
+
+	person, err := fetchTMDBPerson(3359, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	departments := filmography(person, movies, series)
+
+	var names []string
+	for _, department := range departments {
+		names = append(names, department.Department)
+	}
+	if !slices.Equal(names, []string{"Acting", "Directing", "Production"}) {
+		t.Fatalf("departments = %v", names)
+	}
+
+	var acting []string
+	for _, credit := range departments[0].Credits {
+		acting = append(acting, credit.Year+" "+credit.Identifier)
+	}
+	expected := []string{"1940 rebecca", "1948 hamlet-1948", "1981 brideshead-revisited-1981", " untitled-project"}
+	if !slices.Equal(acting, expected) {
+		t.Errorf("acting = %q, want %q", acting, expected)
+	}
+
+	production := departments[2].Credits
+	if len(production) != 1 || production[0].Roles != "Producer, Executive Producer" {
+		t.Errorf("production = %+v, want Hamlet once with both jobs", production)
+	}
+
+	personParsed, err := loadTemplate("", "person.adoc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := renderPerson(personParsed, person, movies, series)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"Notable works::\n- [[hamlet-1948|Hamlet]]",
+		"- [[brideshead-revisited-1981|Brideshead Revisited]]",
+		"== Filmography",
+		"=== Acting",
+		"- 1948: [[hamlet-1948|Hamlet]] as _Hamlet_",
+		"- 1981: [[brideshead-revisited-1981|Brideshead Revisited]] (TV) as _Lord Marchmain_",
+		"- 1948: [[hamlet-1948|Hamlet]] (Producer, Executive Producer)",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("article is missing %q:\n%s", expected, content)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package movies
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"fiatjaf/wiki-importer/common"
+)
+
+// ExportSource says which TMDB daily export to read: a file downloaded
+// beforehand or the one published on Date.
+type ExportSource struct {
+	File string
+	Date time.Time
+}
+
+func NewExportSource(file string, date string) (ExportSource, error) {
+	source := ExportSource{File: file, Date: yesterday}
+
+	if date != "" {
+		parsed, err := time.Parse(time.DateOnly, date)
+		if err != nil {
+			return source, fmt.Errorf("invalid export date %s, expected YYYY-MM-DD", date)
+		}
+		source.Date = parsed
+	}
+
+	return source, nil
+}
+
+type exportReader struct {
+	*gzip.Reader
+	raw io.ReadCloser
+}
+
+func (r exportReader) Close() error {
+	r.Reader.Close()
+	return r.raw.Close()
+}
+
+// open returns the decompressed export, one JSON object per line
+func (s ExportSource) open(format string) (io.ReadCloser, error) {
+	var raw io.ReadCloser
+	if s.File != "" {
+		f, err := os.Open(s.File)
+		if err != nil {
+			return nil, fmt.Errorf("open export file: %w", err)
+		}
+		raw = f
+	} else {
+		exportURL := getExportURL(format, s.Date)
+
+		resp, err := common.HttpGet(exportURL)
+		if err != nil {
+			return nil, fmt.Errorf("download export %s: %w", exportURL, err)
+		}
+		// a missing export is an error page, not a gzip file
+		if resp.StatusCode != 200 {
+			resp.Body.Close()
+			return nil, fmt.Errorf("download export %s: status code %d", exportURL, resp.StatusCode)
+		}
+		raw = resp.Body
+	}
+
+	gr, err := gzip.NewReader(raw)
+	if err != nil {
+		raw.Close()
+		return nil, fmt.Errorf("decompress export: %w", err)
+	}
+
+	return exportReader{Reader: gr, raw: raw}, nil
+}
@@ -0,0 +1,110 @@
+package movies
+
+import (
+	"bufio"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewExportSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		date     string
+		expected ExportSource
+		wantErr  bool
+	}{
+		{
+			name:     "default",
+			expected: ExportSource{Date: yesterday},
+			wantErr:  false,
+		},
+		{
+			name:     "file",
+			file:     "movie_ids_03_01_2024.json.gz",
+			expected: ExportSource{File: "movie_ids_03_01_2024.json.gz", Date: yesterday},
+			wantErr:  false,
+		},
+		{
+			name:     "date",
+			date:     "2024-03-01",
+			expected: ExportSource{Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+			wantErr:  false,
+		},
+		{
+			name:    "bad date",
+			date:    "01/03/2024",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewExportSource(tt.file, tt.date)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewExportSource() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got.File != tt.expected.File || !got.Date.Equal(tt.expected.Date) {
+				t.Errorf("NewExportSource() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExportSourceOpen(t *testing.T) {
+	lines := []string{`{"id":603,"original_title":"The Matrix"}`, `{"id":604,"original_title":"The Matrix Reloaded"}`}
+
+	file := filepath.Join(t.TempDir(), "movie_ids_03_01_2024.json.gz")
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := gzip.NewWriter(f)
+	gw.Write([]byte(strings.Join(lines, "\n") + "\n"))
+	gw.Close()
+	f.Close()
+
+	export, err := ExportSource{File: file}.open(TMDB_MOVIES)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer export.Close()
+
+	var got []string
+	scanner := bufio.NewScanner(export)
+	for scanner.Scan() {
+		got = append(got, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, lines) {
+		t.Errorf("lines = %q, want %q", got, lines)
+	}
+
+	if _, err := (ExportSource{File: filepath.Join(t.TempDir(), "missing.json.gz")}).open(TMDB_MOVIES); err == nil {
+		t.Error("missing file opened")
+	}
+
+	// an export that isn't published yet
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err = ExportSource{Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}.open(server.URL + "/movie_ids_%02d_%02d_%d.json.gz")
+	if err == nil || !strings.Contains(err.Error(), "404") || strings.Contains(err.Error(), "gzip") {
+		t.Errorf("missing export: %v", err)
+	}
+}
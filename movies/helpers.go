@@ -1,10 +1,17 @@
 package movies
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
 )
 
+// tmdbAPI is where TMDB requests go, tests point it at a local server
+var tmdbAPI = "https://api.themoviedb.org/3"
+
 func splitAndWikilink(s string) string {
 	if s != "" {
 		spl := strings.Split(s, ", ")
@@ -16,11 +23,106 @@ func splitAndWikilink(s string) string {
 	return ""
 }
 
-func getYesterdays(format string) string {
+// omdbValue drops the "N/A" OMDB uses for missing fields
+func omdbValue(s string) string {
+	if s == "N/A" {
+		return ""
+	}
+
+	return s
+}
+
+// splitList splits OMDB's comma separated lists
+func splitList(s string) []string {
+	s = omdbValue(s)
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, ", ")
+}
+
+func firstOf(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+func getExportURL(format string, date time.Time) string {
 	return fmt.Sprintf(
 		format,
-		yesterday.Month(),
-		yesterday.Day(),
-		yesterday.Year(),
+		date.Month(),
+		date.Day(),
+		date.Year(),
 	)
 }
+
+// yearOf turns a YYYY-MM-DD date into just the year
+func yearOf(date string) string {
+	if spl := strings.Split(date, "-"); len(spl) == 3 {
+		return spl[0]
+	}
+
+	return date
+}
+
+type publishParams struct {
+	Pool       *nostr.SimplePool
+	RelayURL   string
+	NostrKey   string
+	Title      string
+	Identifier string
+	Content    string
+	// BCP 47 tag, set for localized articles
+	Language string
+}
+
+func publish(ctx context.Context, params publishParams) error {
+	evt := nostr.Event{
+		CreatedAt: nostr.Now(),
+		Kind:      30818,
+		Tags: nostr.Tags{
+			{"title", params.Title},
+			{"d", params.Identifier},
+		},
+		Content: params.Content,
+	}
+
+	if params.Language != "" {
+		evt.Tags = append(evt.Tags,
+			nostr.Tag{"L", "BCP-47"},
+			nostr.Tag{"l", params.Language, "BCP-47"},
+		)
+	}
+
+	if err := evt.Sign(params.NostrKey); err != nil {
+		return fmt.Errorf("sign event: %w", err)
+	}
+
+	relay, err := params.Pool.EnsureRelay(params.RelayURL)
+	if err != nil {
+		return fmt.Errorf("ensure relay: %w", err)
+	}
+
+	if err := relay.Publish(ctx, evt); err != nil {
+		return fmt.Errorf("publish event: %w", err)
+	}
+
+	return nil
+}
+
+func publishDisambiguation(ctx context.Context, pool *nostr.SimplePool, relayURL string, nostrKey string, page *disambiguation) error {
+	return publish(ctx, publishParams{
+		Pool:       pool,
+		RelayURL:   relayURL,
+		NostrKey:   nostrKey,
+		Title:      page.Title,
+		Identifier: page.Identifier,
+		Content:    page.Content,
+		Language:   page.Language,
+	})
+}
@@ -0,0 +1,157 @@
+package movies
+
+import (
+	"fmt"
+	"strings"
+
+	"fiatjaf/wiki-importer/common"
+
+	"github.com/nbd-wtf/go-nostr/nip54"
+)
+
+// identifierIndex gives each TMDB item an identifier so that items with the
+// same title (every "Hamlet", every "John Smith") get distinct ones. The
+// first item with a title gets the bare identifier; once a second one shows
+// up both get qualified identifiers and the bare one becomes a
+// disambiguation page.
+type identifierIndex struct {
+	*common.IdentifierIndex[int]
+}
+
+type indexEntry = common.IdentifierEntry[int]
+
+type assignment struct {
+	Identifier string
+	// entries that had the bare identifier and must be published again
+	Moved []indexEntry
+	// when not empty the bare identifier should get a disambiguation page
+	Candidates []indexEntry
+}
+
+func loadIdentifierIndex(name string) (*identifierIndex, error) {
+	idx, err := common.LoadIdentifierIndex[int](name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &identifierIndex{idx}, nil
+}
+
+// assign returns the identifier for an item. Qualifiers (e.g. a year) are
+// appended in order when the title is taken, with the TMDB ID as last resort.
+func (idx *identifierIndex) assign(id int, title string, qualifiers ...string) (assignment, error) {
+	bare := nip54.NormalizeIdentifier(title)
+
+	if entry, ok := idx.Entry(id); ok && entry.Bare == bare {
+		return assignment{Identifier: entry.Identifier}, nil
+	}
+
+	entry := indexEntry{
+		ID:         id,
+		Title:      title,
+		Bare:       bare,
+		Qualifiers: qualifiers,
+	}
+
+	var others []int
+	for _, other := range idx.WithBare(bare) {
+		if other != id {
+			others = append(others, other)
+		}
+	}
+
+	if len(others) == 0 && !idx.Taken(bare, id) {
+		entry.Identifier = bare
+
+		return assignment{Identifier: bare}, idx.Record(entry)
+	}
+
+	var result assignment
+	if len(others) > 0 {
+		// everybody moves out of the bare identifier
+		for _, other := range others {
+			moved, _ := idx.Entry(other)
+			if moved.Identifier != bare {
+				continue
+			}
+
+			moved.Identifier = idx.Qualify(moved)
+			if err := idx.Record(moved); err != nil {
+				return result, err
+			}
+
+			result.Moved = append(result.Moved, moved)
+		}
+	}
+
+	entry.Identifier = idx.Qualify(entry)
+	if err := idx.Record(entry); err != nil {
+		return result, err
+	}
+	result.Identifier = entry.Identifier
+
+	if len(others) > 0 {
+		for _, candidate := range idx.WithBare(bare) {
+			candidateEntry, _ := idx.Entry(candidate)
+			result.Candidates = append(result.Candidates, candidateEntry)
+		}
+	}
+
+	return result, nil
+}
+
+// disambiguationContent lists every item sharing a title
+func disambiguationContent(title string, candidates []indexEntry) string {
+	content := strings.Builder{}
+	content.WriteString(fmt.Sprintf("*%s* may refer to:\n\n", title))
+
+	for _, candidate := range candidates {
+		label := candidate.Title
+		var details []string
+		for _, qualifier := range candidate.Qualifiers {
+			if qualifier != "" {
+				details = append(details, qualifier)
+			}
+		}
+		if len(details) > 0 {
+			label += " (" + strings.Join(details, ", ") + ")"
+		}
+
+		content.WriteString(fmt.Sprintf("- [[%s|%s]]\n", candidate.Identifier, label))
+	}
+
+	return content.String()
+}
+
+// disambiguation is the page for a bare identifier shared by several items
+type disambiguation struct {
+	Title      string
+	Identifier string
+	Content    string
+	Language   string
+}
+
+// disambiguation returns the page to publish at the bare identifier, or nil
+// when the title isn't shared
+func (a assignment) disambiguation(title string) *disambiguation {
+	if len(a.Candidates) == 0 {
+		return nil
+	}
+
+	return &disambiguation{
+		Title:      title,
+		Identifier: a.Candidates[0].Bare,
+		Content:    disambiguationContent(title, a.Candidates),
+	}
+}
+
+// movedIDs are the items that have to be published again under their new
+// identifiers
+func (a assignment) movedIDs() []int {
+	ids := make([]int, len(a.Moved))
+	for i, moved := range a.Moved {
+		ids[i] = moved.ID
+	}
+
+	return ids
+}
@@ -0,0 +1,92 @@
+package movies
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func chdirTemp(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestIdentifierIndex(t *testing.T) {
+	chdirTemp(t)
+
+	idx, err := loadIdentifierIndex("test.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := idx.assign(10, "Hamlet", "1948")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Identifier != "hamlet" || len(first.Moved) != 0 || len(first.Candidates) != 0 {
+		t.Errorf("first Hamlet = %+v, want the bare identifier", first)
+	}
+
+	again, _ := idx.assign(10, "Hamlet", "1948")
+	if again.Identifier != "hamlet" {
+		t.Errorf("same movie again = %q, want hamlet", again.Identifier)
+	}
+
+	second, err := idx.assign(20, "Hamlet", "1996")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Identifier != "hamlet-1996" {
+		t.Errorf("second Hamlet = %q, want hamlet-1996", second.Identifier)
+	}
+	if !slices.Equal(second.movedIDs(), []int{10}) || second.Moved[0].Identifier != "hamlet-1948" {
+		t.Errorf("moved = %+v, want the 1948 Hamlet under hamlet-1948", second.Moved)
+	}
+
+	page := second.disambiguation("Hamlet")
+	if page == nil || page.Identifier != "hamlet" {
+		t.Fatalf("disambiguation = %+v, want a page at hamlet", page)
+	}
+	for _, link := range []string{"[[hamlet-1948|Hamlet (1948)]]", "[[hamlet-1996|Hamlet (1996)]]"} {
+		if !strings.Contains(page.Content, link) {
+			t.Errorf("disambiguation doesn't link %s:\n%s", link, page.Content)
+		}
+	}
+
+	third, _ := idx.assign(30, "Hamlet", "1996")
+	if third.Identifier != "hamlet-1996-30" || len(third.Moved) != 0 {
+		t.Errorf("third Hamlet = %+v, want hamlet-1996-30 moving nobody", third)
+	}
+	if len(third.Candidates) != 3 {
+		t.Errorf("candidates = %d, want 3", len(third.Candidates))
+	}
+
+	if err := idx.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the assignments survive a restart
+	idx, err = loadIdentifierIndex("test.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	for id, expected := range map[int]string{10: "hamlet-1948", 20: "hamlet-1996", 30: "hamlet-1996-30"} {
+		if got, _ := idx.Lookup(id); got != expected {
+			t.Errorf("lookup(%d) = %q, want %q", id, got, expected)
+		}
+	}
+
+	other, _ := idx.assign(40, "Vertigo", "1958")
+	if other.Identifier != "vertigo" {
+		t.Errorf("unrelated title = %q, want vertigo", other.Identifier)
+	}
+}
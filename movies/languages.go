@@ -0,0 +1,346 @@
+package movies
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/nbd-wtf/go-nostr/nip54"
+)
+
+// Localization publishes movies in several languages. The first one is the
+// primary language: its articles get the identifiers movies always had, from
+// their English titles, whatever language it is. The others get identifiers
+// from their localized titles, kept in an index of their own and never
+// clashing with the identifiers of other languages.
+type Localization struct {
+	Languages []string
+	indexes   map[string]*identifierIndex
+}
+
+// loadLocalization opens the identifier indexes for languages like "en",
+// "pt-BR" or "de". It returns nil when no languages are given, in which case
+// movies are published only in English and without a language tag.
+func loadLocalization(languages []string, primary *identifierIndex) (*Localization, error) {
+	if len(languages) == 0 {
+		return nil, nil
+	}
+
+	l := &Localization{
+		indexes: make(map[string]*identifierIndex),
+	}
+
+	for _, language := range languages {
+		language = strings.TrimSpace(language)
+		if language == "" {
+			continue
+		}
+		for _, existing := range l.Languages {
+			if strings.EqualFold(existing, language) {
+				l.Close()
+				return nil, fmt.Errorf("language %s given twice", language)
+			}
+		}
+		l.Languages = append(l.Languages, language)
+	}
+
+	if len(l.Languages) == 0 {
+		return nil, nil
+	}
+
+	for _, language := range l.Languages[1:] {
+		idx, err := loadIdentifierIndex("movies-identifiers-" + nip54.NormalizeIdentifier(language) + ".jsonl")
+		if err != nil {
+			l.Close()
+			return nil, err
+		}
+		l.indexes[language] = idx
+	}
+
+	all := []*identifierIndex{primary}
+	for _, idx := range l.indexes {
+		all = append(all, idx)
+	}
+	for _, idx := range all {
+		for _, other := range all {
+			if other != idx {
+				idx.Siblings = append(idx.Siblings, other.IdentifierIndex)
+			}
+		}
+	}
+
+	return l, nil
+}
+
+// Close closes the indexes of the secondary languages, the primary one
+// belongs to the caller
+func (l *Localization) Close() {
+	if l == nil {
+		return
+	}
+
+	for _, idx := range l.indexes {
+		idx.Close()
+	}
+}
+
+// languages to publish in, a single unnamed one without localization
+func (l *Localization) languages() []string {
+	if l == nil {
+		return []string{""}
+	}
+
+	return l.Languages
+}
+
+func (l *Localization) isPrimary(language string) bool {
+	return l == nil || language == l.Languages[0]
+}
+
+// index is where the identifiers for a language are kept
+func (l *Localization) index(language string, primary *identifierIndex) *identifierIndex {
+	if l.isPrimary(language) {
+		return primary
+	}
+
+	return l.indexes[language]
+}
+
+// indexTitle is the title a movie's identifier comes from in a language:
+// the English one for the primary language, so that publishing in another
+// language first doesn't change the identifiers already published
+func (l *Localization) indexTitle(language string, english string, localized string) string {
+	if l.isPrimary(language) {
+		return english
+	}
+
+	return localized
+}
+
+// qualifiers tell apart movies with the same title, secondary languages put
+// the language first so their articles don't take the primary ones' place
+func (l *Localization) qualifiers(language string, year string) []string {
+	if l.isPrimary(language) {
+		return []string{year}
+	}
+
+	return []string{language, year}
+}
+
+// localizedTemplate returns a copy of tmpl whose "t" function translates to
+// the language
+func localizedTemplate(tmpl *template.Template, language string) (*template.Template, error) {
+	if language == "" {
+		return tmpl, nil
+	}
+
+	localized, err := tmpl.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("clone template %s: %w", tmpl.Name(), err)
+	}
+
+	return localized.Funcs(template.FuncMap{"t": translator(language)}), nil
+}
+
+// localized returns the movie with its title, overview, tagline and homepage
+// in the language, keeping the original ones where there is no translation
+func (m TMDBMovie) localized(language string) TMDBMovie {
+	if language == "" {
+		return m
+	}
+
+	lang, region, _ := strings.Cut(language, "-")
+
+	var match *TMDBTranslation
+	for i, translation := range m.Translations.Translations {
+		if !strings.EqualFold(translation.Iso6391, lang) {
+			continue
+		}
+		if strings.EqualFold(translation.Iso31661, region) {
+			match = &m.Translations.Translations[i]
+			break
+		}
+		if match == nil {
+			match = &m.Translations.Translations[i]
+		}
+	}
+
+	if match == nil {
+		return m
+	}
+
+	m.Title = firstOf(match.Data.Title, m.Title)
+	m.Overview = firstOf(match.Data.Overview, m.Overview)
+	m.Tagline = firstOf(match.Data.Tagline, m.Tagline)
+	m.Homepage = firstOf(match.Data.Homepage, m.Homepage)
+
+	return m
+}
+
+// translator looks template strings up for a language, falling back to the
+// language without its region and then to the English string itself
+func translator(language string) func(string) string {
+	lang, _, _ := strings.Cut(strings.ToLower(language), "-")
+
+	return func(s string) string {
+		if translated, ok := templateStrings[strings.ToLower(language)][s]; ok {
+			return translated
+		}
+		if translated, ok := templateStrings[lang][s]; ok {
+			return translated
+		}
+		return s
+	}
+}
+
+// templateStrings are the words in the movie templates, by language
+var templateStrings = map[string]map[string]string{
+	"pt": {
+		"Part of":              "Parte de",
+		"is a movie":           "é um filme",
+		"released in":          "lançado em",
+		"written by":           "escrito por",
+		"directed by":          "dirigido por",
+		"and":                  "e",
+		"original":             "original",
+		"as":                   "como",
+		"Plot":                 "Enredo",
+		"Credits":              "Créditos",
+		"Cast":                 "Elenco",
+		"Crew":                 "Equipe",
+		"Directed by":          "Direção",
+		"Written by":           "Roteiro",
+		"Music by":             "Música",
+		"Cinematography by":    "Fotografia",
+		"Produced by":          "Produção",
+		"Awards":               "Prêmios",
+		"Ratings":              "Avaliações",
+		"Source":               "Fonte",
+		"Value":                "Nota",
+		"Votes":                "Votos",
+		"Information":          "Informações",
+		"Release date":         "Data de lançamento",
+		"Runtime":              "Duração",
+		"Rated":                "Classificação",
+		"Produced in":          "Produzido em",
+		"Languages":            "Idiomas",
+		"Budget":               "Orçamento",
+		"Revenue":              "Receita",
+		"Box office":           "Bilheteria",
+		"Popularity":           "Popularidade",
+		"Genres":               "Gêneros",
+		"Homepage":             "Página oficial",
+		"Production companies": "Produtoras",
+	},
+	"es": {
+		"Part of":              "Parte de",
+		"is a movie":           "es una película",
+		"released in":          "estrenada en",
+		"written by":           "escrita por",
+		"directed by":          "dirigida por",
+		"and":                  "y",
+		"original":             "original",
+		"as":                   "como",
+		"Plot":                 "Argumento",
+		"Credits":              "Créditos",
+		"Cast":                 "Reparto",
+		"Crew":                 "Equipo",
+		"Directed by":          "Dirección",
+		"Written by":           "Guion",
+		"Music by":             "Música",
+		"Cinematography by":    "Fotografía",
+		"Produced by":          "Producción",
+		"Awards":               "Premios",
+		"Ratings":              "Valoraciones",
+		"Source":               "Fuente",
+		"Value":                "Nota",
+		"Votes":                "Votos",
+		"Information":          "Información",
+		"Release date":         "Fecha de estreno",
+		"Runtime":              "Duración",
+		"Rated":                "Clasificación",
+		"Produced in":          "Producida en",
+		"Languages":            "Idiomas",
+		"Budget":               "Presupuesto",
+		"Revenue":              "Recaudación",
+		"Box office":           "Taquilla",
+		"Popularity":           "Popularidad",
+		"Genres":               "Géneros",
+		"Homepage":             "Página oficial",
+		"Production companies": "Productoras",
+	},
+	"fr": {
+		"Part of":              "Fait partie de",
+		"is a movie":           "est un film",
+		"released in":          "sorti en",
+		"written by":           "écrit par",
+		"directed by":          "réalisé par",
+		"and":                  "et",
+		"original":             "titre original",
+		"as":                   "dans le rôle de",
+		"Plot":                 "Synopsis",
+		"Credits":              "Fiche technique",
+		"Cast":                 "Distribution",
+		"Crew":                 "Équipe",
+		"Directed by":          "Réalisation",
+		"Written by":           "Scénario",
+		"Music by":             "Musique",
+		"Cinematography by":    "Photographie",
+		"Produced by":          "Production",
+		"Awards":               "Récompenses",
+		"Ratings":              "Notes",
+		"Source":               "Source",
+		"Value":                "Note",
+		"Votes":                "Votes",
+		"Information":          "Informations",
+		"Release date":         "Date de sortie",
+		"Runtime":              "Durée",
+		"Rated":                "Classification",
+		"Produced in":          "Pays de production",
+		"Languages":            "Langues",
+		"Budget":               "Budget",
+		"Revenue":              "Recettes",
+		"Box office":           "Box-office",
+		"Popularity":           "Popularité",
+		"Genres":               "Genres",
+		"Homepage":             "Site officiel",
+		"Production companies": "Sociétés de production",
+	},
+	"de": {
+		"Part of":              "Teil von",
+		"is a movie":           "ist ein Film",
+		"released in":          "erschienen",
+		"written by":           "geschrieben von",
+		"directed by":          "unter der Regie von",
+		"and":                  "und",
+		"original":             "Originaltitel",
+		"as":                   "als",
+		"Plot":                 "Handlung",
+		"Credits":              "Mitwirkende",
+		"Cast":                 "Besetzung",
+		"Crew":                 "Stab",
+		"Directed by":          "Regie",
+		"Written by":           "Drehbuch",
+		"Music by":             "Musik",
+		"Cinematography by":    "Kamera",
+		"Produced by":          "Produktion",
+		"Awards":               "Auszeichnungen",
+		"Ratings":              "Bewertungen",
+		"Source":               "Quelle",
+		"Value":                "Wertung",
+		"Votes":                "Stimmen",
+		"Information":          "Informationen",
+		"Release date":         "Erscheinungsdatum",
+		"Runtime":              "Laufzeit",
+		"Rated":                "Altersfreigabe",
+		"Produced in":          "Produktionsland",
+		"Languages":            "Sprachen",
+		"Budget":               "Budget",
+		"Revenue":              "Einspielergebnis",
+		"Box office":           "Einspielergebnis",
+		"Popularity":           "Beliebtheit",
+		"Genres":               "Genres",
+		"Homepage":             "Webseite",
+		"Production companies": "Produktionsfirmen",
+	},
+}
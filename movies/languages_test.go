@@ -0,0 +1,131 @@
+package movies
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const hamletTranslations = `{"id": 10, "title": "Hamlet", "overview": "The prince of Denmark.", "translations": {"translations": [
+	{"iso_3166_1": "PT", "iso_639_1": "pt", "data": {"title": "Hamlet, o Príncipe", "overview": "O príncipe de Portugal."}},
+	{"iso_3166_1": "BR", "iso_639_1": "pt", "data": {"title": "", "overview": "O príncipe da Dinamarca."}},
+	{"iso_3166_1": "DE", "iso_639_1": "de", "data": {"title": "", "overview": "Der Prinz von Dänemark."}}
+]}}`
+
+func TestLocalizedMovie(t *testing.T) {
+	var movie TMDBMovie
+	if err := json.Unmarshal([]byte(hamletTranslations), &movie); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		language string
+		title    string
+		overview string
+	}{
+		{"", "Hamlet", "The prince of Denmark."},
+		{"pt-BR", "Hamlet", "O príncipe da Dinamarca."},
+		{"pt", "Hamlet, o Príncipe", "O príncipe de Portugal."},
+		{"de-AT", "Hamlet", "Der Prinz von Dänemark."},
+		{"ja", "Hamlet", "The prince of Denmark."},
+	}
+
+	for _, tt := range tests {
+		localized := movie.localized(tt.language)
+		if localized.Title != tt.title || localized.Overview != tt.overview {
+			t.Errorf("localized(%q) = %q, %q, want %q, %q",
+				tt.language, localized.Title, localized.Overview, tt.title, tt.overview)
+		}
+	}
+
+	if movie.Overview != "The prince of Denmark." {
+		t.Errorf("localized changed the original movie")
+	}
+}
+
+func TestTranslator(t *testing.T) {
+	if got := translator("pt-BR")("Plot"); got != "Enredo" {
+		t.Errorf("pt-BR Plot = %q", got)
+	}
+	if got := translator("ja")("Plot"); got != "Plot" {
+		t.Errorf("ja Plot = %q, want the English string", got)
+	}
+
+	tmpl, err := loadTemplate("", "tmdb.adoc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	german, err := localizedTemplate(tmpl, "de")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	movie := TMDBArticle{TMDBMovie: &TMDBMovie{Title: "Hamlet", OriginalTitle: "Hamlet"}}
+	content, err := execute(german, movie)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(content, "== Handlung") || !strings.Contains(content, "Hamlet ist ein Film") {
+		t.Errorf("German article:\n%s", content)
+	}
+
+	// the original template is still in English
+	content, _ = execute(tmpl, movie)
+	if !strings.Contains(content, "== Plot") {
+		t.Errorf("English article:\n%s", content)
+	}
+}
+
+func TestLocalizationIdentifiers(t *testing.T) {
+	chdirTemp(t)
+
+	primary, err := loadIdentifierIndex("movies-identifiers.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer primary.Close()
+
+	l, err := loadLocalization([]string{"en", "pt-BR", "de"}, primary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	var identifiers []string
+	for _, language := range l.languages() {
+		assigned, err := l.index(language, primary).assign(10, "Hamlet", l.qualifiers(language, "1948")...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		identifiers = append(identifiers, assigned.Identifier)
+	}
+
+	expected := "hamlet hamlet-pt-br hamlet-de"
+	if got := strings.Join(identifiers, " "); got != expected {
+		t.Errorf("identifiers = %q, want %q", got, expected)
+	}
+
+	// a title only used in one language stays bare
+	assigned, _ := l.index("de", primary).assign(20, "Die Katze", l.qualifiers("de", "1971")...)
+	if assigned.Identifier != "die-katze" {
+		t.Errorf("German-only title = %q, want die-katze", assigned.Identifier)
+	}
+
+	// a primary language other than English keeps the English identifiers
+	portuguese, err := loadLocalization([]string{"pt", "en"}, primary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer portuguese.Close()
+
+	if title := portuguese.indexTitle("pt", "Hamlet", "Hamlet, o Príncipe"); title != "Hamlet" {
+		t.Errorf("primary pt indexes %q, want Hamlet", title)
+	}
+	if title := l.indexTitle("pt-BR", "Hamlet", "Hamlet, o Príncipe"); title != "Hamlet, o Príncipe" {
+		t.Errorf("secondary pt-BR indexes %q, want the localized title", title)
+	}
+
+	if _, err := loadLocalization([]string{"en", "EN"}, primary); err == nil {
+		t.Error("repeated language accepted")
+	}
+}
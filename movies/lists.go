@@ -0,0 +1,358 @@
+package movies
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"fiatjaf/wiki-importer/common"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip54"
+)
+
+// Collections, companies and genres are published as articles listing
+// their movies, so the wikilinks in movie articles point somewhere.
+
+type ListParams struct {
+	Start        uint64
+	Export       ExportSource
+	Pool         *nostr.SimplePool
+	Logger       *log.Logger
+	TmdbApiKey   string
+	TmdbNostrKey string
+	TmdbRelay    string
+	Parsed       *template.Template
+	// where movies were published, for linking to them
+	Movies *identifierIndex
+	// where each kind of list was published, they share names too
+	Collections *identifierIndex
+	Companies   *identifierIndex
+	Genres      *identifierIndex
+	// most popular movies listed for a company or a genre, 0 for all
+	MaxMovies int
+	Policy    ContentPolicy
+}
+
+// ListedMovie is a movie in a list article
+type ListedMovie struct {
+	Title      string
+	Year       string
+	Identifier string
+}
+
+type collectionArticle struct {
+	TMDBCollection
+	Movies []ListedMovie
+}
+
+type companyArticle struct {
+	TMDBCompany
+	Movies []ListedMovie
+}
+
+type genreArticle struct {
+	TMDBGenre
+	Movies []ListedMovie
+}
+
+func collections(ctx context.Context, params ListParams) error {
+	return eachExportLine(params, TMDB_COLLECTIONS, "collection", func(id int) error {
+		return publishCollection(ctx, params, id)
+	})
+}
+
+// publishCollection publishes a collection with all of its movies
+func publishCollection(ctx context.Context, params ListParams, id int) error {
+	var collection TMDBCollection
+	if err := tmdbGet(fmt.Sprintf("/collection/%d", id), params.TmdbApiKey, &collection); err != nil {
+		return err
+	}
+
+	slices.SortStableFunc(collection.Parts, func(a, b TMDBMovieRef) int {
+		// unreleased parts go last
+		return cmp.Compare(firstOf(a.ReleaseDate, "9999"), firstOf(b.ReleaseDate, "9999"))
+	})
+
+	content, err := execute(params.Parsed, collectionArticle{
+		TMDBCollection: collection,
+		Movies:         listedMovies(collection.Parts, params.Movies, params.Policy),
+	})
+	if err != nil {
+		return err
+	}
+
+	assigned, err := params.Collections.assign(collection.ID, collection.Name)
+	if err != nil {
+		return err
+	}
+
+	return publishList(ctx, params, "collection", collection.Name, content, assigned, func(id int) error {
+		return publishCollection(ctx, params, id)
+	})
+}
+
+func companies(ctx context.Context, params ListParams) error {
+	return eachExportLine(params, TMDB_COMPANIES, "company", func(id int) error {
+		return publishCompany(ctx, params, id)
+	})
+}
+
+// publishCompany publishes a company with its most popular movies, along
+// with the companies that had to move away from the same name and the
+// disambiguation page for that name
+func publishCompany(ctx context.Context, params ListParams, id int) error {
+	var company TMDBCompany
+	if err := tmdbGet(fmt.Sprintf("/company/%d", id), params.TmdbApiKey, &company); err != nil {
+		return err
+	}
+
+	movies, err := discoverMovies(fmt.Sprintf("with_companies=%d", id), params)
+	if err != nil {
+		return err
+	}
+
+	content, err := execute(params.Parsed, companyArticle{
+		TMDBCompany: company,
+		Movies:      movies,
+	})
+	if err != nil {
+		return err
+	}
+
+	assigned, err := params.Companies.assign(company.ID, company.Name, company.OriginCountry)
+	if err != nil {
+		return err
+	}
+
+	return publishList(ctx, params, "company", company.Name, content, assigned, func(id int) error {
+		return publishCompany(ctx, params, id)
+	})
+}
+
+// publishList publishes a list article under the identifier it was
+// assigned, then the ones that had to move away from the same name, through
+// republish, and the disambiguation page for that name
+func publishList(
+	ctx context.Context,
+	params ListParams,
+	kind string,
+	title string,
+	content string,
+	assigned assignment,
+	republish func(id int) error,
+) error {
+	if err := publish(ctx, publishParams{
+		Pool:       params.Pool,
+		RelayURL:   params.TmdbRelay,
+		NostrKey:   params.TmdbNostrKey,
+		Title:      title,
+		Identifier: assigned.Identifier,
+		Content:    content,
+	}); err != nil {
+		return err
+	}
+
+	for _, moved := range assigned.movedIDs() {
+		if err := republish(moved); err != nil {
+			params.Logger.Printf("Error publishing moved TMDB %s - ID: %d, %v\n", kind, moved, err)
+		}
+	}
+
+	if page := assigned.disambiguation(title); page != nil {
+		if err := publishDisambiguation(ctx, params.Pool, params.TmdbRelay, params.TmdbNostrKey, page); err != nil {
+			return fmt.Errorf("publish %s disambiguation: %w", kind, err)
+		}
+	}
+
+	return nil
+}
+
+// genres publishes every movie genre, there are few enough that TMDB lists
+// them all at once instead of in an export
+func genres(ctx context.Context, params ListParams) error {
+	var list struct {
+		Genres []TMDBGenre `json:"genres"`
+	}
+	if err := tmdbGet("/genre/movie/list", params.TmdbApiKey, &list); err != nil {
+		return err
+	}
+
+	byID := make(map[int]TMDBGenre)
+	for _, genre := range list.Genres {
+		byID[genre.ID] = genre
+	}
+
+	var publishGenre func(genre TMDBGenre) error
+	publishGenre = func(genre TMDBGenre) error {
+		movies, err := discoverMovies(fmt.Sprintf("with_genres=%d", genre.ID), params)
+		if err != nil {
+			return err
+		}
+
+		content, err := execute(params.Parsed, genreArticle{
+			TMDBGenre: genre,
+			Movies:    movies,
+		})
+		if err != nil {
+			return err
+		}
+
+		assigned, err := params.Genres.assign(genre.ID, genre.Name)
+		if err != nil {
+			return err
+		}
+
+		return publishList(ctx, params, "genre", genre.Name, content, assigned, func(id int) error {
+			return publishGenre(byID[id])
+		})
+	}
+
+	for i, genre := range list.Genres {
+		if uint64(i) < params.Start {
+			continue
+		}
+
+		params.Logger.Printf("Processing TMDB genre - ID: %d, %s, index: %d\n", genre.ID, genre.Name, i)
+
+		if err := publishGenre(genre); err != nil {
+			params.Logger.Printf("Error processing TMDB genre - index: %d, %v\n", i, err)
+		}
+	}
+
+	return nil
+}
+
+// eachExportLine calls process with the ID in every line of an export,
+// logging the errors
+func eachExportLine(params ListParams, format string, kind string, process func(id int) error) error {
+	export, err := params.Export.open(format)
+	if err != nil {
+		return err
+	}
+	defer export.Close()
+
+	i := uint64(0)
+	scanner := bufio.NewScanner(export)
+	for scanner.Scan() {
+		if i < params.Start {
+			i++
+
+			continue
+		}
+
+		var line struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			params.Logger.Printf("Error unmarshalling TMDB %s - index: %d, %v\n", kind, i, err)
+		} else {
+			params.Logger.Printf("Processing TMDB %s - ID: %d, %s, index: %d\n", kind, line.ID, line.Name, i)
+
+			if err := process(line.ID); err != nil {
+				params.Logger.Printf("Error processing TMDB %s - index: %d, %v\n", kind, i, err)
+			}
+		}
+
+		i++
+
+		// Add a small delay between requests to respect rate limits
+		time.Sleep(1 * time.Second)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read %s export: %w", kind, err)
+	}
+
+	return nil
+}
+
+// discoverMovies gets the most popular movies matching a discover filter, as
+// many as params.MaxMovies or all of them when it is 0
+func discoverMovies(filter string, params ListParams) ([]ListedMovie, error) {
+	var refs []TMDBMovieRef
+
+	for page, totalPages := 1, 1; page <= totalPages && (params.MaxMovies == 0 || len(refs) < params.MaxMovies); page++ {
+		var result struct {
+			Results    []TMDBMovieRef `json:"results"`
+			TotalPages int            `json:"total_pages"`
+		}
+		if err := tmdbGet(
+			fmt.Sprintf("/discover/movie?%s&sort_by=popularity.desc&page=%d", filter, page),
+			params.TmdbApiKey,
+			&result,
+		); err != nil {
+			return nil, err
+		}
+
+		refs = append(refs, result.Results...)
+		totalPages = result.TotalPages
+	}
+
+	if params.MaxMovies > 0 && len(refs) > params.MaxMovies {
+		refs = refs[0:params.MaxMovies]
+	}
+
+	return listedMovies(refs, params.Movies, params.Policy), nil
+}
+
+func listedMovies(refs []TMDBMovieRef, movies *identifierIndex, policy ContentPolicy) []ListedMovie {
+	var listed []ListedMovie
+	for _, ref := range refs {
+		if ref.Title == "" || policy.skips(ref.Adult, ref.Video) {
+			continue
+		}
+
+		listed = append(listed, ListedMovie{
+			Title:      ref.Title,
+			Year:       yearOf(ref.ReleaseDate),
+			Identifier: indexedIdentifier(movies, ref.ID, ref.Title),
+		})
+	}
+
+	return listed
+}
+
+// indexedIdentifier is where a movie or series was published, or where it
+// will be if its title is not shared when it is
+func indexedIdentifier(idx *identifierIndex, id int, title string) string {
+	if idx != nil {
+		if identifier, ok := idx.Lookup(id); ok {
+			return identifier
+		}
+	}
+
+	return nip54.NormalizeIdentifier(title)
+}
+
+// tmdbGet decodes the response for an API path into v
+func tmdbGet(path string, tmdbApiKey string, v any) error {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+
+	resp, err := common.HttpGet(tmdbAPI + path + separator + "api_key=" + tmdbApiKey)
+	if err != nil {
+		return fmt.Errorf("fetch TMDB %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("fetch TMDB %s: status code %d", path, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode TMDB %s: %w", path, err)
+	}
+
+	return nil
+}
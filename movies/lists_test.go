@@ -0,0 +1,79 @@
+package movies
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestListedMovies(t *testing.T) {
+	chdirTemp(t)
+
+	movies, err := loadIdentifierIndex("movies.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer movies.Close()
+	movies.assign(1, "Hamlet", "1948")
+	movies.assign(2, "Hamlet", "1996")
+
+	listed := listedMovies([]TMDBMovieRef{
+		{ID: 1, Title: "Hamlet", ReleaseDate: "1948-05-04"},
+		{ID: 3, Title: "Henry V", ReleaseDate: "1944-11-22"},
+		{ID: 4, Title: "Something Else", Adult: true},
+		{ID: 5, Title: "Behind the Scenes", Video: true},
+	}, movies, ContentPolicy{SkipAdult: true, SkipVideo: true})
+
+	if len(listed) != 2 {
+		t.Fatalf("listed = %+v, want two movies", listed)
+	}
+	if listed[0].Identifier != "hamlet-1948" || listed[0].Year != "1948" {
+		t.Errorf("first = %+v, want the identifier from the index", listed[0])
+	}
+	if listed[1].Identifier != "henry-v" {
+		t.Errorf("second = %+v, want the identifier from the title", listed[1])
+	}
+
+	tmpl, err := loadTemplate("", "collection.adoc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := execute(tmpl, collectionArticle{
+		TMDBCollection: TMDBCollection{Name: "Olivier's Shakespeare"},
+		Movies:         listed,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(content, "[[hamlet-1948|Hamlet]] (1948)") || !strings.Contains(content, "of 2 movies") {
+		t.Errorf("collection article:\n%s", content)
+	}
+}
+
+func TestDiscoverMovies(t *testing.T) {
+	serveTMDB(t, map[string]string{
+		"/discover/movie?": `{"results": [
+			{"id": 1, "title": "Hamlet", "release_date": "1948-05-04"},
+			{"id": 2, "title": "Rebecca", "release_date": "1940-03-27"},
+			{"id": 3, "title": "Henry V", "release_date": "1944-11-22"}
+		], "total_pages": 1}`,
+	})
+
+	tests := []struct {
+		maxMovies int
+		expected  int
+	}{
+		{0, 3},
+		{2, 2},
+		{5, 3},
+	}
+
+	for _, tt := range tests {
+		listed, err := discoverMovies("with_genres=18", ListParams{MaxMovies: tt.maxMovies})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(listed) != tt.expected {
+			t.Errorf("max %d listed %d movies, want %d", tt.maxMovies, len(listed), tt.expected)
+		}
+	}
+}
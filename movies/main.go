@@ -4,8 +4,9 @@ import (
 	"context"
 	"embed"
 	"fmt"
+	"io"
 	"log"
-	"text/template"
+	"os"
 	"time"
 
 	"fiatjaf/wiki-importer/common"
@@ -17,89 +18,451 @@ import (
 const (
 	TMDB_MOVIES  = "http://files.tmdb.org/p/exports/movie_ids_%02d_%02d_%d.json.gz"
 	TMDB_PERSONS = "http://files.tmdb.org/p/exports/person_ids_%02d_%02d_%d.json.gz"
+	TMDB_TV      = "http://files.tmdb.org/p/exports/tv_series_ids_%02d_%02d_%d.json.gz"
+
+	TMDB_COLLECTIONS = "http://files.tmdb.org/p/exports/collection_ids_%02d_%02d_%d.json.gz"
+	TMDB_COMPANIES   = "http://files.tmdb.org/p/exports/production_company_ids_%02d_%02d_%d.json.gz"
 )
 
-//go:embed tmdb.adoc omdb.adoc person.adoc
+//go:embed tmdb.adoc omdb.adoc merged.adoc person.adoc tv.adoc season.adoc collection.adoc company.adoc genre.adoc
 var templates embed.FS
 
 var (
 	yesterday = time.Now().AddDate(0, 0, -1)
 )
 
+// MovieOptions are the flags shared by everything that publishes movies
+type MovieOptions struct {
+	// cast members listed per movie, 0 for all
+	CastLimit uint64
+	// one article with both sources instead of one for each
+	Merged bool
+	// directory with template overrides
+	Templates string
+	// languages to publish in, the first one being the primary
+	Languages []string
+}
+
+func movieOptions(c *cli.Command) MovieOptions {
+	return MovieOptions{
+		CastLimit: c.Uint("cast-limit"),
+		Merged:    c.Bool("merged"),
+		Templates: c.String("templates"),
+		Languages: c.StringSlice("languages"),
+	}
+}
+
 func HandleMovies(ctx context.Context, l *log.Logger, c *cli.Command) error {
 	startIndex := c.Uint("continue")
 
-	return runMovies(ctx, l, startIndex)
+	export, err := NewExportSource(c.String("export-file"), c.String("export-date"))
+	if err != nil {
+		return err
+	}
+
+	return runMovies(ctx, l, startIndex, export, movieOptions(c))
 }
 
 func HandlePersons(ctx context.Context, l *log.Logger, c *cli.Command) error {
 	startIndex := c.Uint("continue")
 
-	return runPersons(ctx, l, startIndex)
+	export, err := NewExportSource(c.String("export-file"), c.String("export-date"))
+	if err != nil {
+		return err
+	}
+
+	return runPersons(ctx, l, startIndex, export, c.String("templates"))
 }
 
-func runMovies(ctx context.Context, l *log.Logger, startIndex uint64) error {
-	tmdbApiKey, err := common.GetRequiredEnv("TMDB_API_KEY")
+func HandleTv(ctx context.Context, l *log.Logger, c *cli.Command) error {
+	startIndex := c.Uint("continue")
+
+	export, err := NewExportSource(c.String("export-file"), c.String("export-date"))
 	if err != nil {
 		return err
 	}
 
-	tmdbNostrKey, err := common.GetRequiredEnv("TMDB_NOSTR_KEY")
+	return runTv(ctx, l, startIndex, export, c.Bool("seasons"), c.String("templates"))
+}
+
+func HandleSync(ctx context.Context, l *log.Logger, c *cli.Command) error {
+	var since time.Time
+	if date := c.String("since"); date != "" {
+		var err error
+		if since, err = time.Parse(time.DateOnly, date); err != nil {
+			return fmt.Errorf("invalid since date %q: %w", date, err)
+		}
+	}
+
+	return runSync(ctx, l, since, movieOptions(c), ContentPolicy{
+		SkipAdult: !c.Bool("include-adult"),
+		SkipVideo: !c.Bool("include-video"),
+	})
+}
+
+func HandleRender(ctx context.Context, l *log.Logger, c *cli.Command) error {
+	id := c.Uint("id")
+	if id == 0 {
+		return fmt.Errorf("--id is required")
+	}
+
+	return runRender(os.Stdout, int(id), c.Bool("person"), movieOptions(c))
+}
+
+func HandleCollections(ctx context.Context, l *log.Logger, c *cli.Command) error {
+	export, err := NewExportSource(c.String("export-file"), c.String("export-date"))
 	if err != nil {
 		return err
 	}
 
-	tmdbRelay, err := common.GetRequiredEnv("TMDB_RELAY")
+	return runLists(ctx, l, "collection.adoc", c.String("templates"), func(params ListParams) error {
+		params.Start = c.Uint("continue")
+		params.Export = export
+
+		params.Collections, err = loadIdentifierIndex("collections-identifiers.jsonl")
+		if err != nil {
+			return err
+		}
+		defer params.Collections.Close()
+
+		return collections(ctx, params)
+	})
+}
+
+func HandleCompanies(ctx context.Context, l *log.Logger, c *cli.Command) error {
+	export, err := NewExportSource(c.String("export-file"), c.String("export-date"))
 	if err != nil {
 		return err
 	}
 
-	tmdbParsed, err := template.ParseFS(templates, "tmdb.adoc")
+	return runLists(ctx, l, "company.adoc", c.String("templates"), func(params ListParams) error {
+		params.Start = c.Uint("continue")
+		params.Export = export
+		params.MaxMovies = int(c.Uint("max-movies"))
+
+		params.Companies, err = loadIdentifierIndex("companies-identifiers.jsonl")
+		if err != nil {
+			return err
+		}
+		defer params.Companies.Close()
+
+		return companies(ctx, params)
+	})
+}
+
+func HandleGenres(ctx context.Context, l *log.Logger, c *cli.Command) error {
+	return runLists(ctx, l, "genre.adoc", c.String("templates"), func(params ListParams) error {
+		params.Start = c.Uint("continue")
+		params.MaxMovies = int(c.Uint("max-movies"))
+
+		var err error
+		params.Genres, err = loadIdentifierIndex("genres-identifiers.jsonl")
+		if err != nil {
+			return err
+		}
+		defer params.Genres.Close()
+
+		return genres(ctx, params)
+	})
+}
+
+// runLists reads what every list importer needs and runs one of them
+func runLists(ctx context.Context, l *log.Logger, templateName string, templatesDir string, run func(ListParams) error) error {
+	params := ListParams{
+		Pool:   nostr.NewSimplePool(ctx),
+		Logger: l,
+		// discover leaves these out too
+		Policy: ContentPolicy{SkipAdult: true, SkipVideo: true},
+	}
+
+	var err error
+
+	params.Parsed, err = loadTemplate(templatesDir, templateName)
+	if err != nil {
+		return err
+	}
+
+	params.TmdbApiKey, err = common.GetRequiredEnv("TMDB_API_KEY")
+	if err != nil {
+		return err
+	}
+
+	params.TmdbNostrKey, err = common.GetRequiredEnv("TMDB_NOSTR_KEY")
+	if err != nil {
+		return err
+	}
+
+	params.TmdbRelay, err = common.GetRequiredEnv("TMDB_RELAY")
+	if err != nil {
+		return err
+	}
+
+	// list articles link to movies under the identifiers they got
+	params.Movies, err = loadIdentifierIndex("movies-identifiers.jsonl")
 	if err != nil {
-		return fmt.Errorf("parse TMDB template: %w", err)
+		return err
 	}
+	defer params.Movies.Close()
+
+	return run(params)
+}
 
-	omdbApiKey, err := common.GetRequiredEnv("OMDB_API_KEY")
+func runMovies(ctx context.Context, l *log.Logger, startIndex uint64, export ExportSource, options MovieOptions) error {
+	params, err := newMoviesParams(ctx, l, options)
 	if err != nil {
 		return err
 	}
+	defer params.Identifiers.Close()
+	defer params.Localization.Close()
+
+	params.Start = startIndex
+	params.Export = export
+
+	return movies(ctx, params)
+}
+
+// newMoviesParams reads the keys and templates for publishing movies, either
+// as TMDB and OMDB articles or as merged ones. The identifier index it opens
+// has to be closed by the caller.
+func newMoviesParams(ctx context.Context, l *log.Logger, options MovieOptions) (MoviesParams, error) {
+	params := MoviesParams{
+		Pool:      nostr.NewSimplePool(ctx),
+		Logger:    l,
+		CastLimit: options.CastLimit,
+	}
+
+	var err error
+
+	params.TmdbApiKey, err = common.GetRequiredEnv("TMDB_API_KEY")
+	if err != nil {
+		return params, err
+	}
+
+	params.TmdbNostrKey, err = common.GetRequiredEnv("TMDB_NOSTR_KEY")
+	if err != nil {
+		return params, err
+	}
+
+	params.TmdbRelay, err = common.GetRequiredEnv("TMDB_RELAY")
+	if err != nil {
+		return params, err
+	}
 
-	omdbNostrKey, err := common.GetRequiredEnv("OMDB_NOSTR_KEY")
+	params.OmdbApiKey, err = common.GetRequiredEnv("OMDB_API_KEY")
+	if err != nil {
+		return params, err
+	}
+
+	if options.Merged {
+		params.MergedParsed, err = loadTemplate(options.Templates, "merged.adoc")
+		if err != nil {
+			return params, err
+		}
+	} else {
+		params.TmdbParsed, err = loadTemplate(options.Templates, "tmdb.adoc")
+		if err != nil {
+			return params, err
+		}
+
+		params.OmdbNostrKey, err = common.GetRequiredEnv("OMDB_NOSTR_KEY")
+		if err != nil {
+			return params, err
+		}
+
+		params.OmdbRelay, err = common.GetRequiredEnv("OMDB_RELAY")
+		if err != nil {
+			return params, err
+		}
+
+		params.OmdbParsed, err = loadTemplate(options.Templates, "omdb.adoc")
+		if err != nil {
+			return params, err
+		}
+	}
+
+	params.Identifiers, err = loadIdentifierIndex("movies-identifiers.jsonl")
+	if err != nil {
+		return params, err
+	}
+
+	params.Localization, err = loadLocalization(options.Languages, params.Identifiers)
+	if err != nil {
+		params.Identifiers.Close()
+		return params, err
+	}
+
+	return params, nil
+}
+
+func runPersons(ctx context.Context, l *log.Logger, startIndex uint64, export ExportSource, templatesDir string) error {
+	params, err := newPersonsParams(ctx, l, templatesDir)
 	if err != nil {
 		return err
 	}
+	defer params.Identifiers.Close()
+	defer params.Movies.Close()
+	defer params.Series.Close()
+
+	params.Start = startIndex
+	params.Export = export
+
+	return persons(ctx, params)
+}
+
+// newPersonsParams reads the keys and the template for publishing persons.
+// Both identifier indexes it opens have to be closed by the caller.
+func newPersonsParams(ctx context.Context, l *log.Logger, templatesDir string) (PersonsParams, error) {
+	params := PersonsParams{
+		Pool:   nostr.NewSimplePool(ctx),
+		Logger: l,
+	}
+
+	var err error
+
+	params.PersonParsed, err = loadTemplate(templatesDir, "person.adoc")
+	if err != nil {
+		return params, err
+	}
+
+	params.TmdbApiKey, err = common.GetRequiredEnv("TMDB_API_KEY")
+	if err != nil {
+		return params, err
+	}
+
+	params.TmdbNostrKey, err = common.GetRequiredEnv("TMDB_NOSTR_KEY")
+	if err != nil {
+		return params, err
+	}
+
+	params.TmdbRelay, err = common.GetRequiredEnv("TMDB_RELAY")
+	if err != nil {
+		return params, err
+	}
+
+	params.Identifiers, err = loadIdentifierIndex("persons-identifiers.jsonl")
+	if err != nil {
+		return params, err
+	}
+
+	// filmographies link to movies and series under the identifiers they got
+	params.Movies, err = loadIdentifierIndex("movies-identifiers.jsonl")
+	if err != nil {
+		params.Identifiers.Close()
+		return params, err
+	}
 
-	omdbRelay, err := common.GetRequiredEnv("OMDB_RELAY")
+	params.Series, err = loadIdentifierIndex("tv-identifiers.jsonl")
+	if err != nil {
+		params.Identifiers.Close()
+		params.Movies.Close()
+		return params, err
+	}
+
+	return params, nil
+}
+
+func runSync(ctx context.Context, l *log.Logger, since time.Time, options MovieOptions, policy ContentPolicy) error {
+	moviesParams, err := newMoviesParams(ctx, l, options)
 	if err != nil {
 		return err
 	}
+	defer moviesParams.Identifiers.Close()
+	defer moviesParams.Localization.Close()
 
-	omdbParsed, err := template.ParseFS(templates, "omdb.adoc")
+	personsParams, err := newPersonsParams(ctx, l, options.Templates)
 	if err != nil {
-		return fmt.Errorf("parse OMDB template: %w", err)
+		return err
 	}
+	defer personsParams.Identifiers.Close()
+	defer personsParams.Series.Close()
+
+	// share the movies index, so filmographies see the identifiers given
+	// during this sync
+	personsParams.Movies.Close()
+	personsParams.Movies = moviesParams.Identifiers
 
-	movies(ctx, MoviesParams{
-		Start:        startIndex,
-		Pool:         nostr.NewSimplePool(ctx),
-		Logger:       l,
-		TmdbApiKey:   tmdbApiKey,
-		TmdbNostrKey: tmdbNostrKey,
-		TmdbRelay:    tmdbRelay,
-		TmdbParsed:   tmdbParsed,
-		OmdbApiKey:   omdbApiKey,
-		OmdbNostrKey: omdbNostrKey,
-		OmdbRelay:    omdbRelay,
-		OmdbParsed:   omdbParsed,
+	return runChangesSync(ctx, SyncParams{
+		Since:   since,
+		Policy:  policy,
+		Movies:  moviesParams,
+		Persons: personsParams,
 	})
+}
+
+func runRender(w io.Writer, id int, person bool, options MovieOptions) error {
+	params := RenderParams{
+		ID:        id,
+		Person:    person,
+		CastLimit: options.CastLimit,
+	}
+
+	var err error
+
+	params.TmdbApiKey, err = common.GetRequiredEnv("TMDB_API_KEY")
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case person:
+		params.PersonParsed, err = loadTemplate(options.Templates, "person.adoc")
+		if err != nil {
+			return err
+		}
+
+		params.Movies, err = loadIdentifierIndex("movies-identifiers.jsonl")
+		if err != nil {
+			return err
+		}
+		defer params.Movies.Close()
+
+		params.Series, err = loadIdentifierIndex("tv-identifiers.jsonl")
+		if err != nil {
+			return err
+		}
+		defer params.Series.Close()
+
+	case options.Merged:
+		params.OmdbApiKey, err = common.GetRequiredEnv("OMDB_API_KEY")
+		if err != nil {
+			return err
+		}
+
+		params.MergedParsed, err = loadTemplate(options.Templates, "merged.adoc")
+		if err != nil {
+			return err
+		}
+
+	default:
+		params.TmdbParsed, err = loadTemplate(options.Templates, "tmdb.adoc")
+		if err != nil {
+			return err
+		}
+	}
+
+	if person || len(options.Languages) == 0 {
+		return render(w, params)
+	}
+
+	for _, language := range options.Languages {
+		params.Language = language
+		if err := render(w, params); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
-func runPersons(ctx context.Context, l *log.Logger, startIndex uint64) error {
-	personParsed, err := template.ParseFS(templates, "person.adoc")
+func runTv(ctx context.Context, l *log.Logger, startIndex uint64, export ExportSource, seasons bool, templatesDir string) error {
+	tvParsed, err := loadTemplate(templatesDir, "tv.adoc")
+	if err != nil {
+		return err
+	}
+
+	seasonParsed, err := loadTemplate(templatesDir, "season.adoc")
 	if err != nil {
-		return fmt.Errorf("parse person template: %w", err)
+		return err
 	}
 
 	tmdbApiKey, err := common.GetRequiredEnv("TMDB_API_KEY")
@@ -117,17 +480,30 @@ func runPersons(ctx context.Context, l *log.Logger, startIndex uint64) error {
 		return err
 	}
 
-	pool := nostr.NewSimplePool(ctx)
+	identifiers, err := loadIdentifierIndex("tv-identifiers.jsonl")
+	if err != nil {
+		return err
+	}
+	defer identifiers.Close()
 
-	persons(ctx, PersonsParams{
-		Start:        startIndex,
-		Pool:         pool,
-		Logger:       l,
-		PersonParsed: personParsed,
-		TmdbApiKey:   tmdbApiKey,
-		TmdbNostrKey: tmdbNostrKey,
-		TmdbRelay:    tmdbRelay,
-	})
+	seasonIdentifiers, err := loadIdentifierIndex("seasons-identifiers.jsonl")
+	if err != nil {
+		return err
+	}
+	defer seasonIdentifiers.Close()
 
-	return nil
+	return tv(ctx, TvParams{
+		Start:             startIndex,
+		Export:            export,
+		Pool:              nostr.NewSimplePool(ctx),
+		Logger:            l,
+		TmdbApiKey:        tmdbApiKey,
+		TmdbNostrKey:      tmdbNostrKey,
+		TmdbRelay:         tmdbRelay,
+		TvParsed:          tvParsed,
+		SeasonParsed:      seasonParsed,
+		Seasons:           seasons,
+		Identifiers:       identifiers,
+		SeasonIdentifiers: seasonIdentifiers,
+	})
 }
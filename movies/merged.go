@@ -0,0 +1,281 @@
+package movies
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"text/template"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// MergedMovie is what we know about a movie from both TMDB and OMDB, each
+// field taken from whichever source has it.
+type MergedMovie struct {
+	TMDBId        int
+	ImdbID        string
+	Title         string
+	OriginalTitle string
+	Year          string
+	Released      bool
+	// ISO date, empty when unknown
+	ReleaseDate string
+	Tagline     string
+	Overview    string
+	Poster      string
+	// minutes
+	Runtime          int
+	Rated            string
+	Directors        []string
+	Writers          []string
+	Composers        []string
+	Cinematographers []string
+	Producers        []string
+	Cast             []MergedCastMember
+	Collection       string
+	Genres           []string
+	Companies        []string
+	Countries        []string
+	Languages        []string
+	Budget           int
+	Revenue          int
+	// dollars, as OMDB counts them
+	BoxOffice  int
+	Awards     string
+	Ratings    []Rating
+	Popularity float64
+	Homepage   string
+}
+
+type MergedCastMember struct {
+	Name      string
+	Character string
+}
+
+type MergedParams struct {
+	Index        uint64
+	Line         []byte
+	Logger       *log.Logger
+	TmdbApiKey   string
+	OmdbApiKey   string
+	NostrKey     string
+	Relay        string
+	Pool         *nostr.SimplePool
+	MergedParsed *template.Template
+	Identifiers  *identifierIndex
+	CastLimit    uint64
+	Policy       ContentPolicy
+	Localization *Localization
+}
+
+// merged publishes a single article with the data of both sources under the
+// TMDB key, instead of one TMDB and one OMDB article
+func merged(ctx context.Context, params MergedParams) (TMDBResult, error) {
+	index := params.Index
+	logger := params.Logger
+
+	empty := TMDBResult{}
+
+	var tmdbMovie TMDBMovie
+	if err := json.Unmarshal(params.Line, &tmdbMovie); err != nil {
+		return empty, fmt.Errorf("unmarshal TMDB movie - index: %d, %w", index, err)
+	}
+
+	logger.Printf("Processing merged movie - ID: %d, index: %d\n", tmdbMovie.ID, index)
+
+	tmdbMovie, err := fetchTMDBMovie(tmdbMovie.ID, params.TmdbApiKey)
+	if err != nil {
+		return empty, err
+	}
+
+	if params.Policy.skips(tmdbMovie.Adult, tmdbMovie.Video) {
+		return empty, fmt.Errorf("TMDB movie %d: %w", tmdbMovie.ID, errSkipped)
+	}
+
+	// TMDB alone is still worth publishing
+	omdbMovie, err := fetchOMDBMovie(tmdbMovie.omdbLookup(), params.OmdbApiKey)
+	if errors.Is(err, errNoOMDBMatch) {
+		logger.Printf("No OMDB movie to merge - index: %d, %v\n", index, err)
+	} else if err != nil {
+		logger.Printf("Error fetching OMDB movie - index: %d, %v\n", index, err)
+	}
+
+	var result TMDBResult
+
+	for _, language := range params.Localization.languages() {
+		localized, err := publishMergedMovie(ctx, params, tmdbMovie, omdbMovie, language)
+		if err != nil {
+			return result, fmt.Errorf("merged movie - language: %s, index: %d, %w", language, index, err)
+		}
+
+		moved := result.Moved
+		if params.Localization.isPrimary(language) {
+			result = localized
+		}
+		for _, id := range append(moved, localized.Moved...) {
+			if !slices.Contains(result.Moved, id) {
+				result.Moved = append(result.Moved, id)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func publishMergedMovie(ctx context.Context, params MergedParams, tmdbMovie TMDBMovie, omdbMovie OMDBMovie, language string) (TMDBResult, error) {
+	empty := TMDBResult{}
+
+	tmpl, err := localizedTemplate(params.MergedParsed, language)
+	if err != nil {
+		return empty, err
+	}
+
+	english := mergeMovies(tmdbMovie, omdbMovie).Title
+	movie := mergeMovies(tmdbMovie.localized(language), omdbMovie)
+
+	content, err := renderMergedMovie(tmpl, movie, params.CastLimit)
+	if err != nil {
+		return empty, err
+	}
+
+	indexTitle := params.Localization.indexTitle(language, english, movie.Title)
+	identifiers := params.Localization.index(language, params.Identifiers)
+	assigned, err := identifiers.assign(
+		movie.TMDBId,
+		indexTitle,
+		params.Localization.qualifiers(language, movie.Year)...,
+	)
+	if err != nil {
+		return empty, err
+	}
+
+	if err := publish(ctx, publishParams{
+		Pool:       params.Pool,
+		RelayURL:   params.Relay,
+		NostrKey:   params.NostrKey,
+		Title:      movie.Title,
+		Identifier: assigned.Identifier,
+		Content:    content,
+		Language:   language,
+	}); err != nil {
+		return empty, fmt.Errorf("publish merged movie: %w", err)
+	}
+
+	result := TMDBResult{
+		TMDBId:               movie.TMDBId,
+		IMDBId:               movie.ImdbID,
+		NormalizedIdentifier: assigned.Identifier,
+		Moved:                assigned.movedIDs(),
+		Disambiguation:       assigned.disambiguation(indexTitle),
+	}
+
+	if result.Disambiguation != nil {
+		result.Disambiguation.Language = language
+
+		if err := publishDisambiguation(ctx, params.Pool, params.Relay, params.NostrKey, result.Disambiguation); err != nil {
+			return result, fmt.Errorf("publish merged disambiguation: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+func renderMergedMovie(mergedParsed *template.Template, movie MergedMovie, castLimit uint64) (string, error) {
+	if castLimit > 0 && uint64(len(movie.Cast)) > castLimit {
+		movie.Cast = movie.Cast[0:castLimit]
+	}
+
+	return execute(mergedParsed, movie)
+}
+
+func mergeMovies(t TMDBMovie, omdbMovie OMDBMovie) MergedMovie {
+	o := normalizeOMDBMovie(omdbMovie)
+
+	m := MergedMovie{
+		TMDBId:           t.ID,
+		ImdbID:           firstOf(t.ImdbID, o.ImdbID),
+		Title:            firstOf(t.Title, o.Title),
+		OriginalTitle:    t.OriginalTitle,
+		Year:             firstOf(yearOf(isoDate(t.ReleaseDate)), o.Year),
+		Released:         t.Status == "Released" || o.Released != "",
+		ReleaseDate:      firstOf(isoDate(t.ReleaseDate), o.Released),
+		Tagline:          t.Tagline,
+		Overview:         firstOf(t.Overview, o.Plot),
+		Runtime:          t.Runtime,
+		Rated:            o.Rated,
+		Directors:        t.Directors(),
+		Writers:          t.Writers(),
+		Composers:        t.Composers(),
+		Cinematographers: t.Cinematographers(),
+		Producers:        t.Producers(),
+		Budget:           t.Budget,
+		Revenue:          t.Revenue,
+		BoxOffice:        o.BoxOffice,
+		Awards:           o.Awards,
+		Ratings:          append(o.Ratings, tmdbRatings(t)...),
+		Popularity:       t.Popularity,
+		Homepage:         firstOf(t.Homepage, o.Website),
+	}
+
+	if t.BelongsToCollection != nil {
+		m.Collection = t.BelongsToCollection.Name
+	}
+
+	if t.PosterPath != "" {
+		m.Poster = "https://media.themoviedb.org/t/p/w300_and_h450_bestv2" + t.PosterPath
+	} else {
+		m.Poster = o.Poster
+	}
+
+	if m.Runtime == 0 {
+		m.Runtime = o.Runtime
+	}
+
+	for _, member := range t.Cast {
+		m.Cast = append(m.Cast, MergedCastMember{Name: member.Name, Character: member.Character})
+	}
+	if len(m.Cast) == 0 {
+		for _, name := range splitList(o.Actors) {
+			m.Cast = append(m.Cast, MergedCastMember{Name: name})
+		}
+	}
+	if len(m.Directors) == 0 {
+		m.Directors = splitList(o.Director)
+	}
+	if len(m.Writers) == 0 {
+		m.Writers = splitList(o.Writer)
+	}
+
+	for _, genre := range t.Genres {
+		m.Genres = append(m.Genres, genre.Name)
+	}
+	if len(m.Genres) == 0 {
+		m.Genres = splitList(o.Genre)
+	}
+
+	for _, company := range t.ProductionCompanies {
+		m.Companies = append(m.Companies, company.Name)
+	}
+	if len(m.Companies) == 0 {
+		m.Companies = splitList(o.Production)
+	}
+
+	for _, country := range t.ProductionCountries {
+		m.Countries = append(m.Countries, country.Name)
+	}
+	if len(m.Countries) == 0 {
+		m.Countries = splitList(o.Country)
+	}
+
+	for _, language := range t.SpokenLanguages {
+		m.Languages = append(m.Languages, language.EnglishName)
+	}
+	if len(m.Languages) == 0 {
+		m.Languages = splitList(o.Language)
+	}
+
+	return m
+}
@@ -0,0 +1,132 @@
+package movies
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMergeMovies(t *testing.T) {
+	matrixOMDB := OMDBMovie{
+		Title:      "Matrix",
+		Year:       "1999",
+		Rated:      "R",
+		Released:   "31 Mar 1999",
+		Runtime:    "136 min",
+		Genre:      "Action, Sci-Fi",
+		Director:   "Lana Wachowski, Lilly Wachowski",
+		Writer:     "Lilly Wachowski, Lana Wachowski",
+		Actors:     "Keanu Reeves, Laurence Fishburne",
+		Plot:       "A hacker learns what the Matrix is.",
+		Language:   "English",
+		Country:    "United States, Australia",
+		Awards:     "Won 4 Oscars",
+		Poster:     "https://m.media-amazon.com/images/matrix.jpg",
+		ImdbID:     "tt0133093",
+		BoxOffice:  "$172,076,928",
+		Production: "N/A",
+		Website:    "N/A",
+	}
+
+	tests := []struct {
+		name     string
+		tmdb     string
+		omdb     OMDBMovie
+		expected MergedMovie
+	}{
+		{
+			name: "TMDB first",
+			tmdb: `{
+				"id": 603, "imdb_id": "tt0133093", "title": "The Matrix", "original_title": "The Matrix",
+				"release_date": "1999-03-30", "status": "Released", "tagline": "Welcome to the Real World.",
+				"overview": "Set in the 22nd century.", "poster_path": "/matrix.jpg", "runtime": 136,
+				"budget": 63000000, "revenue": 463517383, "homepage": "http://www.warnerbros.com/matrix",
+				"belongs_to_collection": {"id": 2344, "name": "The Matrix Collection"},
+				"genres": [{"id": 28, "name": "Action"}],
+				"production_companies": [{"id": 79, "name": "Village Roadshow Pictures"}],
+				"production_countries": [{"iso_3166_1": "US", "name": "United States of America"}],
+				"spoken_languages": [{"english_name": "English", "iso_639_1": "en", "name": "English"}],
+				"cast": [{"name": "Keanu Reeves", "character": "Neo"}]
+			}`,
+			omdb: matrixOMDB,
+			expected: MergedMovie{
+				TMDBId:        603,
+				ImdbID:        "tt0133093",
+				Title:         "The Matrix",
+				OriginalTitle: "The Matrix",
+				Year:          "1999",
+				Released:      true,
+				ReleaseDate:   "1999-03-30",
+				Tagline:       "Welcome to the Real World.",
+				Overview:      "Set in the 22nd century.",
+				Poster:        "https://media.themoviedb.org/t/p/w300_and_h450_bestv2/matrix.jpg",
+				Runtime:       136,
+				Rated:         "R",
+				Cast:          []MergedCastMember{{Name: "Keanu Reeves", Character: "Neo"}},
+				Directors:     []string{"Lana Wachowski", "Lilly Wachowski"},
+				Writers:       []string{"Lilly Wachowski", "Lana Wachowski"},
+				Collection:    "The Matrix Collection",
+				Genres:        []string{"Action"},
+				Companies:     []string{"Village Roadshow Pictures"},
+				Countries:     []string{"United States of America"},
+				Languages:     []string{"English"},
+				Budget:        63000000,
+				Revenue:       463517383,
+				BoxOffice:     172076928,
+				Awards:        "Won 4 Oscars",
+				Homepage:      "http://www.warnerbros.com/matrix",
+			},
+		},
+		{
+			name: "OMDB fallbacks",
+			tmdb: `{"id": 603}`,
+			omdb: matrixOMDB,
+			expected: MergedMovie{
+				TMDBId:      603,
+				ImdbID:      "tt0133093",
+				Title:       "Matrix",
+				Year:        "1999",
+				Released:    true,
+				ReleaseDate: "1999-03-31",
+				Overview:    "A hacker learns what the Matrix is.",
+				Poster:      "https://m.media-amazon.com/images/matrix.jpg",
+				Runtime:     136,
+				Rated:       "R",
+				Cast:        []MergedCastMember{{Name: "Keanu Reeves"}, {Name: "Laurence Fishburne"}},
+				Directors:   []string{"Lana Wachowski", "Lilly Wachowski"},
+				Writers:     []string{"Lilly Wachowski", "Lana Wachowski"},
+				Genres:      []string{"Action", "Sci-Fi"},
+				Countries:   []string{"United States", "Australia"},
+				Languages:   []string{"English"},
+				BoxOffice:   172076928,
+				Awards:      "Won 4 Oscars",
+			},
+		},
+		{
+			name: "TMDB alone",
+			tmdb: `{"id": 603, "title": "The Matrix", "release_date": "1999-03-30", "status": "Released", "vote_average": 8.216, "vote_count": 26000}`,
+			expected: MergedMovie{
+				TMDBId:      603,
+				Title:       "The Matrix",
+				Year:        "1999",
+				Released:    true,
+				ReleaseDate: "1999-03-30",
+				Ratings:     []Rating{{Source: "TMDB", Score: 8.2, Max: 10, Votes: 26000}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tmdbMovie TMDBMovie
+			if err := json.Unmarshal([]byte(tt.tmdb), &tmdbMovie); err != nil {
+				t.Fatal(err)
+			}
+
+			got := mergeMovies(tmdbMovie, tt.omdb)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("mergeMovies() = \n%+v\n<<WANT>>\n%+v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -2,20 +2,27 @@ package movies
 
 import (
 	"bufio"
-	"compress/gzip"
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"slices"
 	"text/template"
 
-	"fiatjaf/wiki-importer/common"
-
 	"github.com/nbd-wtf/go-nostr"
 )
 
 type MoviesParams struct {
-	Start        uint64
-	Pool         *nostr.SimplePool
-	Logger       *log.Logger
+	Start       uint64
+	Export      ExportSource
+	Pool        *nostr.SimplePool
+	Logger      *log.Logger
+	Identifiers *identifierIndex
+	// cast members listed per movie, 0 for all
+	CastLimit uint64
+	Policy    ContentPolicy
+	// nil to publish only in English
+	Localization *Localization
 	TmdbApiKey   string
 	TmdbNostrKey string
 	TmdbRelay    string
@@ -24,83 +31,166 @@ type MoviesParams struct {
 	OmdbNostrKey string
 	OmdbRelay    string
 	OmdbParsed   *template.Template
+	// publish one article with both sources instead of one for each
+	MergedParsed *template.Template
 }
 
-func movies(ctx context.Context, params MoviesParams) {
-	start := params.Start
-	tmdbApiKey := params.TmdbApiKey
-	tmdbNostrKey := params.TmdbNostrKey
-	tmdbRelay := params.TmdbRelay
-	omdbApiKey := params.OmdbApiKey
-	omdbNostrKey := params.OmdbNostrKey
-	omdbRelay := params.OmdbRelay
-	omdbParsed := params.OmdbParsed
-	pool := params.Pool
-	logger := params.Logger
-	tmdbParsed := params.TmdbParsed
-
-	resp, err := common.HttpGet(getYesterdays(TMDB_MOVIES))
-	if err != nil {
-		panic(err)
-	}
-	defer resp.Body.Close()
-
-	gr, err := gzip.NewReader(resp.Body)
+func movies(ctx context.Context, params MoviesParams) error {
+	export, err := params.Export.open(TMDB_MOVIES)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	defer gr.Close()
+	defer export.Close()
 
 	i := uint64(0)
-	scanner := bufio.NewScanner(gr)
+	failed := 0
+	scanner := bufio.NewScanner(export)
 	for scanner.Scan() {
-		if i < start {
+		if i < params.Start {
 			i++
 
 			continue
 		}
 
-		// TMDB
-		tmdbResult, err := tmdb(ctx, NewTmdbParams(
-			i,
-			scanner.Bytes(),
-			logger,
-			tmdbApiKey,
-			tmdbNostrKey,
-			tmdbRelay,
-			pool,
-			tmdbParsed,
-		))
+		if err := processMovie(ctx, params, i, slices.Clone(scanner.Bytes())); err != nil {
+			failed++
+		}
+
+		i++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read movies export: %w", err)
+	}
+
+	if failed > 0 {
+		params.Logger.Printf("%d movies failed, see the errors above\n", failed)
+	}
+
+	return nil
+}
+
+// processMovie publishes one line of the export and whatever had to move
+// away from its title, returning the error of the line itself
+func processMovie(ctx context.Context, params MoviesParams, i uint64, line []byte) error {
+	var failed error
+
+	pending := [][]byte{line}
+	for first := true; len(pending) > 0; first = false {
+		line := pending[0]
+		pending = pending[1:]
+
+		result, err := movie(ctx, params, i, line)
+		if errors.Is(err, errSkipped) {
+			params.Logger.Printf("Skipped movie - index: %d, %v\n", i, err)
+
+			continue
+		}
 		if err != nil {
-			logger.Printf("Error processing TMDB movie - index: %d, %v\n", i, err)
+			params.Logger.Printf("Error processing movie - index: %d, %v\n", i, err)
+			if first {
+				failed = err
+			}
 
 			continue
 		}
+
+		for _, id := range result.Moved {
+			pending = append(pending, []byte(fmt.Sprintf(`{"id":%d}`, id)))
+		}
+	}
+
+	return failed
+}
+
+// movie publishes one line of the export, as a merged article or as one
+// TMDB and one OMDB article
+func movie(ctx context.Context, params MoviesParams, i uint64, line []byte) (TMDBResult, error) {
+	logger := params.Logger
+
+	if params.MergedParsed != nil {
+		result, err := merged(ctx, MergedParams{
+			Index:        i,
+			Line:         line,
+			Logger:       logger,
+			TmdbApiKey:   params.TmdbApiKey,
+			OmdbApiKey:   params.OmdbApiKey,
+			NostrKey:     params.TmdbNostrKey,
+			Relay:        params.TmdbRelay,
+			Pool:         params.Pool,
+			MergedParsed: params.MergedParsed,
+			Identifiers:  params.Identifiers,
+			CastLimit:    params.CastLimit,
+			Policy:       params.Policy,
+			Localization: params.Localization,
+		})
+		if err != nil {
+			return result, err
+		}
+
 		logger.Printf(
-			"Processed TMDB movie - ID: %d, %s, IMDBId: %s, index: %d\n",
-			tmdbResult.TMDBId,
-			tmdbResult.NormalizedIdentifier,
-			tmdbResult.IMDBId,
+			"Processed merged movie - ID: %d, %s, IMDBId: %s, index: %d\n",
+			result.TMDBId,
+			result.NormalizedIdentifier,
+			result.IMDBId,
 			i,
 		)
 
-		// OMDB
-		if err := omdb(ctx, NewOmdbParams(
-			i,
-			tmdbResult.IMDBId,
-			tmdbResult.NormalizedIdentifier,
-			omdbApiKey,
-			omdbNostrKey,
-			omdbRelay,
-			pool,
-			logger,
-			omdbParsed,
-		)); err != nil {
-			logger.Printf("Error processing OMDB movie - index: %d, %v\n", i, err)
+		return result, nil
+	}
 
-			continue
+	// TMDB
+	tmdbResult, err := tmdb(ctx, NewTmdbParams(
+		i,
+		line,
+		logger,
+		params.TmdbApiKey,
+		params.TmdbNostrKey,
+		params.TmdbRelay,
+		params.Pool,
+		params.TmdbParsed,
+		params.Identifiers,
+		params.CastLimit,
+		params.Policy,
+		params.Localization,
+	))
+	if err != nil {
+		return tmdbResult, err
+	}
+	logger.Printf(
+		"Processed TMDB movie - ID: %d, %s, IMDBId: %s, index: %d\n",
+		tmdbResult.TMDBId,
+		tmdbResult.NormalizedIdentifier,
+		tmdbResult.IMDBId,
+		i,
+	)
+
+	// OMDB
+	if tmdbResult.Disambiguation != nil {
+		if err := publishDisambiguation(ctx, params.Pool, params.OmdbRelay, params.OmdbNostrKey, tmdbResult.Disambiguation); err != nil {
+			logger.Printf("Error publishing OMDB disambiguation - index: %d, %v\n", i, err)
 		}
+	}
 
-		i++
+	if err := omdb(ctx, NewOmdbParams(
+		i,
+		OMDBLookup{
+			ImdbID: tmdbResult.IMDBId,
+			Title:  tmdbResult.Title,
+			Year:   tmdbResult.Year,
+		},
+		tmdbResult.NormalizedIdentifier,
+		params.OmdbApiKey,
+		params.OmdbNostrKey,
+		params.OmdbRelay,
+		params.Pool,
+		logger,
+		params.OmdbParsed,
+	)); errors.Is(err, errNoOMDBMatch) {
+		logger.Printf("No OMDB movie to publish - index: %d, %v\n", i, err)
+	} else if err != nil {
+		logger.Printf("Error processing OMDB movie - index: %d, %v\n", i, err)
 	}
+
+	return tmdbResult, nil
 }
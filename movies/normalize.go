@@ -0,0 +1,196 @@
+package movies
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OMDB and TMDB give the same things in different shapes: OMDB has strings
+// like "N/A", "136 min", "$171,479,930" and "31 Mar 1999" where TMDB has
+// numbers and ISO dates. Both are normalized here before they reach the
+// templates.
+
+// Rating is a score from one source, the same for OMDB and TMDB ratings
+type Rating struct {
+	Source string
+	Score  float64
+	// the best possible score, 10 or 100
+	Max float64
+	// 0 when the source doesn't say
+	Votes int
+	// scores like Rotten Tomatoes' are percentages instead of points
+	Percentage bool
+}
+
+// Value is the score as its source shows it, "8.7/10" or "88%"
+func (r Rating) Value() string {
+	score := strconv.FormatFloat(r.Score, 'f', -1, 64)
+	if r.Percentage {
+		return score + "%"
+	}
+
+	return score + "/" + strconv.FormatFloat(r.Max, 'f', -1, 64)
+}
+
+// Percent is the score out of 100, to compare sources
+func (r Rating) Percent() int {
+	if r.Max == 0 {
+		return 0
+	}
+
+	return int(r.Score/r.Max*100 + 0.5)
+}
+
+// OMDBArticle is an OMDB movie as its template sees it, with "N/A" fields
+// empty, the release date in ISO format and typed runtime, box office and
+// ratings
+type OMDBArticle struct {
+	OMDBMovie
+	// minutes
+	Runtime int
+	// dollars
+	BoxOffice int
+	Ratings   []Rating
+}
+
+func normalizeOMDBMovie(o OMDBMovie) OMDBArticle {
+	for _, field := range []*string{
+		&o.Title, &o.Year, &o.Rated, &o.Released, &o.Runtime, &o.Genre,
+		&o.Director, &o.Writer, &o.Actors, &o.Plot, &o.Language, &o.Country,
+		&o.Awards, &o.Poster, &o.Metascore, &o.ImdbRating, &o.ImdbVotes,
+		&o.ImdbID, &o.Type, &o.Dvd, &o.BoxOffice, &o.Production, &o.Website,
+	} {
+		*field = omdbValue(*field)
+	}
+
+	o.Released = isoDate(o.Released)
+	o.Dvd = isoDate(o.Dvd)
+
+	return OMDBArticle{
+		OMDBMovie: o,
+		Runtime:   parseRuntime(o.Runtime),
+		BoxOffice: parseAmount(o.BoxOffice),
+		Ratings:   omdbRatings(o),
+	}
+}
+
+// omdbRatings parses OMDB's ratings, taking the vote count for IMDB's from
+// imdbVotes
+func omdbRatings(o OMDBMovie) []Rating {
+	var ratings []Rating
+	for _, r := range o.Ratings {
+		rating, ok := parseRating(r.Source, r.Value)
+		if !ok {
+			continue
+		}
+		if r.Source == "Internet Movie Database" {
+			rating.Votes = parseAmount(o.ImdbVotes)
+		}
+		ratings = append(ratings, rating)
+	}
+
+	return ratings
+}
+
+// tmdbRatings is TMDB's own rating, when anyone voted
+func tmdbRatings(t TMDBMovie) []Rating {
+	if t.VoteCount == 0 {
+		return nil
+	}
+
+	return []Rating{{
+		Source: "TMDB",
+		Score:  float64(int(t.VoteAverage*10+0.5)) / 10,
+		Max:    10,
+		Votes:  t.VoteCount,
+	}}
+}
+
+// parseRating reads OMDB rating values like "8.7/10", "73/100" and "88%"
+func parseRating(source string, value string) (Rating, bool) {
+	rating := Rating{Source: source}
+
+	value = strings.TrimSpace(omdbValue(value))
+	if score, ok := strings.CutSuffix(value, "%"); ok {
+		value = score + "/100"
+		rating.Percentage = true
+	}
+
+	score, max, ok := strings.Cut(value, "/")
+	if !ok {
+		return rating, false
+	}
+
+	var err error
+	if rating.Score, err = strconv.ParseFloat(score, 64); err != nil {
+		return rating, false
+	}
+	if rating.Max, err = strconv.ParseFloat(max, 64); err != nil || rating.Max <= 0 {
+		return rating, false
+	}
+
+	return rating, true
+}
+
+// isoDate turns TMDB's "1999-03-31" and OMDB's "31 Mar 1999" into
+// "1999-03-31", and anything else into ""
+func isoDate(s string) string {
+	for _, layout := range []string{time.DateOnly, "02 Jan 2006"} {
+		if t, err := time.Parse(layout, strings.TrimSpace(s)); err == nil {
+			return t.Format(time.DateOnly)
+		}
+	}
+
+	return ""
+}
+
+// parseRuntime reads "136 min" as 136
+func parseRuntime(s string) int {
+	minutes, _ := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(omdbValue(s), "min")))
+	return minutes
+}
+
+// parseAmount reads "$171,479,930" as 171479930
+func parseAmount(s string) int {
+	s = strings.NewReplacer("$", "", ",", "").Replace(strings.TrimSpace(omdbValue(s)))
+	amount, _ := strconv.Atoi(s)
+	return amount
+}
+
+// formatRuntime writes 136 minutes as "2h 16min"
+func formatRuntime(minutes int) string {
+	switch {
+	case minutes <= 0:
+		return ""
+	case minutes < 60:
+		return fmt.Sprintf("%dmin", minutes)
+	case minutes%60 == 0:
+		return fmt.Sprintf("%dh", minutes/60)
+	default:
+		return fmt.Sprintf("%dh %dmin", minutes/60, minutes%60)
+	}
+}
+
+// formatNumber writes 1234.5678 as "1,234.6"
+func formatNumber(n float64) string {
+	s := strconv.FormatFloat(n, 'f', 1, 64)
+	whole, fraction, _ := strings.Cut(s, ".")
+
+	i, _ := strconv.Atoi(whole)
+	if fraction == "0" {
+		return thousands(i)
+	}
+	if i == 0 && strings.HasPrefix(whole, "-") {
+		return "-0." + fraction
+	}
+
+	return thousands(i) + "." + fraction
+}
+
+// TMDBArticle is a TMDB movie as its template sees it
+type TMDBArticle struct {
+	*TMDBMovie
+	Ratings []Rating
+}
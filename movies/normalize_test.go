@@ -0,0 +1,86 @@
+package movies
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNormalizeOMDBMovie(t *testing.T) {
+	movie := OMDBMovie{
+		Title:     "The Matrix",
+		Released:  "31 Mar 1999",
+		Runtime:   "136 min",
+		BoxOffice: "$171,479,930",
+		Awards:    "N/A",
+		Dvd:       "N/A",
+		ImdbVotes: "2,091,542",
+		Ratings: []struct {
+			Source string `json:"Source"`
+			Value  string `json:"Value"`
+		}{
+			{"Internet Movie Database", "8.7/10"},
+			{"Rotten Tomatoes", "88%"},
+			{"Metacritic", "73/100"},
+			{"Somewhere", "N/A"},
+		},
+	}
+
+	article := normalizeOMDBMovie(movie)
+
+	if article.Released != "1999-03-31" {
+		t.Errorf("Released = %q", article.Released)
+	}
+	if article.Awards != "" || article.Dvd != "" {
+		t.Errorf("N/A kept: %q, %q", article.Awards, article.Dvd)
+	}
+	if article.Runtime != 136 || article.BoxOffice != 171479930 {
+		t.Errorf("Runtime = %d, BoxOffice = %d", article.Runtime, article.BoxOffice)
+	}
+
+	var values []string
+	for _, rating := range article.Ratings {
+		values = append(values, rating.Value())
+	}
+	if !slices.Equal(values, []string{"8.7/10", "88%", "73/100"}) {
+		t.Errorf("ratings = %v", values)
+	}
+	if article.Ratings[0].Votes != 2091542 || article.Ratings[0].Percent() != 87 {
+		t.Errorf("IMDB rating = %+v", article.Ratings[0])
+	}
+}
+
+func TestTMDBRatings(t *testing.T) {
+	if ratings := tmdbRatings(TMDBMovie{VoteAverage: 7}); ratings != nil {
+		t.Errorf("rating without votes: %+v", ratings)
+	}
+
+	ratings := tmdbRatings(TMDBMovie{VoteAverage: 8.217, VoteCount: 25000})
+	if len(ratings) != 1 || ratings[0].Value() != "8.2/10" || ratings[0].Votes != 25000 {
+		t.Errorf("ratings = %+v", ratings)
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	for date, want := range map[string]string{
+		"1999-03-31":  "1999-03-31",
+		"31 Mar 1999": "1999-03-31",
+		"N/A":         "",
+		"1999":        "",
+	} {
+		if got := isoDate(date); got != want {
+			t.Errorf("isoDate(%q) = %q, want %q", date, got, want)
+		}
+	}
+
+	for minutes, want := range map[int]string{0: "", 45: "45min", 120: "2h", 136: "2h 16min"} {
+		if got := formatRuntime(minutes); got != want {
+			t.Errorf("formatRuntime(%d) = %q, want %q", minutes, got, want)
+		}
+	}
+
+	for n, want := range map[float64]string{0: "0", 12.345: "12.3", 1234.56: "1,234.6", 2000: "2,000"} {
+		if got := formatNumber(n); got != want {
+			t.Errorf("formatNumber(%v) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -1,21 +1,27 @@
 package movies
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/url"
+	"strings"
 	"text/template"
 
 	"fiatjaf/wiki-importer/common"
 
 	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip54"
 )
 
+// errNoOMDBMatch is returned when OMDB doesn't have the movie looked up
+var errNoOMDBMatch = errors.New("no OMDB match")
+
 type OmdbParams struct {
 	Index                uint64
-	ImdbId               string
+	Lookup               OMDBLookup
 	NormalizedIdentifier string
 	OmdbApiKey           string
 	OmdbNostrKey         string
@@ -27,7 +33,7 @@ type OmdbParams struct {
 
 func NewOmdbParams(
 	index uint64,
-	imdbId string,
+	lookup OMDBLookup,
 	normalizedIdentifier string,
 	omdbApiKey string,
 	omdbNostrKey string,
@@ -38,7 +44,7 @@ func NewOmdbParams(
 ) OmdbParams {
 	return OmdbParams{
 		Index:                index,
-		ImdbId:               imdbId,
+		Lookup:               lookup,
 		NormalizedIdentifier: normalizedIdentifier,
 		OmdbApiKey:           omdbApiKey,
 		OmdbNostrKey:         omdbNostrKey,
@@ -49,66 +55,140 @@ func NewOmdbParams(
 	}
 }
 
+// OMDBLookup finds a movie by its IMDB ID or, for movies TMDB has no IMDB ID
+// for, by its title and year
+type OMDBLookup struct {
+	ImdbID string
+	Title  string
+	Year   string
+}
+
 func omdb(ctx context.Context, params OmdbParams) error {
 	index := params.Index
-	imdbId := params.ImdbId
 	normalizedIdentifier := params.NormalizedIdentifier
-	omdbApiKey := params.OmdbApiKey
-	omdbNostrKey := params.OmdbNostrKey
-	omdbRelay := params.OmdbRelay
-	pool := params.Pool
 	logger := params.Logger
-	omdbParsed := params.OmdbParsed
 
-	resp, err := common.HttpGet(fmt.Sprintf("https://www.omdbapi.com/?i=%s&plot=full&apikey=%s", imdbId, omdbApiKey))
+	movie, err := fetchOMDBMovie(params.Lookup, params.OmdbApiKey)
 	if err != nil {
 		return fmt.Errorf("error fetching OMDB movie - index: %d, %w", index, err)
 	}
 
-	var movie OMDBMovie
-	if err := json.NewDecoder(resp.Body).Decode(&movie); err != nil {
-		resp.Body.Close()
-		return fmt.Errorf("error decoding OMDB movie - index: %d, %w", index, err)
-	}
-	resp.Body.Close()
-
 	logger.Printf(
 		"Processing OMDB movie - index: %d, %s, IMDBId: %s\n",
 		index,
 		normalizedIdentifier,
-		imdbId,
+		movie.ImdbID,
 	)
 
-	movie.Director = splitAndWikilink(movie.Director)
-	movie.Writer = splitAndWikilink(movie.Writer)
-	movie.Actors = splitAndWikilink(movie.Actors)
-	movie.Genre = splitAndWikilink(movie.Genre)
+	article := normalizeOMDBMovie(movie)
+	article.Director = splitAndWikilink(article.Director)
+	article.Writer = splitAndWikilink(article.Writer)
+	article.Actors = splitAndWikilink(article.Actors)
+	article.Genre = splitAndWikilink(article.Genre)
 
-	content := &bytes.Buffer{}
-	if err := omdbParsed.Execute(content, movie); err != nil {
+	content, err := execute(params.OmdbParsed, article)
+	if err != nil {
 		return fmt.Errorf("error executing OMDB template - index: %d, %w", index, err)
 	}
 
-	evt := nostr.Event{
-		CreatedAt: nostr.Now(),
-		Kind:      30818,
-		Tags: nostr.Tags{
-			{"title", movie.Title},
-			{"d", normalizedIdentifier},
-		},
-		Content: content.String(),
+	if err := publish(ctx, publishParams{
+		Pool:       params.Pool,
+		RelayURL:   params.OmdbRelay,
+		NostrKey:   params.OmdbNostrKey,
+		Title:      article.Title,
+		Identifier: normalizedIdentifier,
+		Content:    content,
+	}); err != nil {
+		return fmt.Errorf("error publishing OMDB event - index: %d, %w", index, err)
 	}
 
-	evt.Sign(omdbNostrKey)
+	return nil
+}
+
+// fetchOMDBMovie looks a movie up, returning errNoOMDBMatch when OMDB doesn't
+// have it or only has a different one
+func fetchOMDBMovie(lookup OMDBLookup, omdbApiKey string) (OMDBMovie, error) {
+	var movie OMDBMovie
 
-	relay, err := pool.EnsureRelay(omdbRelay)
+	query, err := lookup.query()
 	if err != nil {
-		return fmt.Errorf("error ensuring OMDB relay - index: %d, %w", index, err)
+		return movie, err
 	}
+	query.Set("plot", "full")
+	query.Set("apikey", omdbApiKey)
 
-	if err := relay.Publish(ctx, evt); err != nil {
-		return fmt.Errorf("error publishing OMDB event - index: %d, %w", index, err)
+	resp, err := common.HttpGet("https://www.omdbapi.com/?" + query.Encode())
+	if err != nil {
+		return movie, err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(&movie); err != nil {
+		return movie, fmt.Errorf("decode: %w", err)
+	}
+
+	if err := lookup.check(movie); err != nil {
+		return OMDBMovie{}, err
+	}
+
+	return movie, nil
+}
+
+func (l OMDBLookup) query() (url.Values, error) {
+	query := url.Values{}
+
+	switch {
+	case l.ImdbID != "":
+		query.Set("i", l.ImdbID)
+	case l.Title != "":
+		query.Set("t", l.Title)
+		query.Set("type", "movie")
+		if l.Year != "" {
+			query.Set("y", l.Year)
+		}
+	default:
+		return nil, fmt.Errorf("no IMDB ID or title to look up: %w", errNoOMDBMatch)
+	}
+
+	return query, nil
+}
+
+// check turns OMDB's error responses into errors. OMDB answers title lookups
+// with its closest match, which is only taken when it has the same title and
+// year.
+func (l OMDBLookup) check(movie OMDBMovie) error {
+	if movie.Response == "False" {
+		// "Movie not found!" and "Incorrect IMDb ID." mean there is nothing
+		// to publish, anything else like "Invalid API key!" is a real error
+		if strings.Contains(movie.Error, "not found") || strings.Contains(movie.Error, "Incorrect IMDb ID") {
+			return fmt.Errorf("%s: %w", movie.Error, errNoOMDBMatch)
+		}
+
+		return fmt.Errorf("OMDB error: %s", firstOf(movie.Error, "unknown"))
+	}
+
+	if l.ImdbID != "" {
+		if !strings.EqualFold(movie.ImdbID, l.ImdbID) {
+			return fmt.Errorf("got %s for %s: %w", movie.ImdbID, l.ImdbID, errNoOMDBMatch)
+		}
+
+		return nil
+	}
+
+	if nip54.NormalizeIdentifier(movie.Title) != nip54.NormalizeIdentifier(l.Title) {
+		return fmt.Errorf("got %q for %q: %w", movie.Title, l.Title, errNoOMDBMatch)
+	}
+	if l.Year != "" && !strings.HasPrefix(movie.Year, l.Year) {
+		return fmt.Errorf("got %q from %s for %q from %s: %w", movie.Title, movie.Year, l.Title, l.Year, errNoOMDBMatch)
 	}
 
 	return nil
 }
+
+func (m TMDBMovie) omdbLookup() OMDBLookup {
+	return OMDBLookup{
+		ImdbID: m.ImdbID,
+		Title:  m.Title,
+		Year:   yearOf(m.ReleaseDate),
+	}
+}
@@ -0,0 +1,57 @@
+package movies
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOMDBLookupQuery(t *testing.T) {
+	query, err := OMDBLookup{ImdbID: "tt0133093", Title: "The Matrix", Year: "1999"}.query()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("i") != "tt0133093" || query.Has("t") {
+		t.Errorf("IMDB ID lookup: %s", query.Encode())
+	}
+
+	query, err = OMDBLookup{Title: "The Matrix", Year: "1999"}.query()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("t") != "The Matrix" || query.Get("y") != "1999" || query.Get("type") != "movie" {
+		t.Errorf("title lookup: %s", query.Encode())
+	}
+
+	if _, err := (OMDBLookup{}).query(); !errors.Is(err, errNoOMDBMatch) {
+		t.Errorf("empty lookup: %v", err)
+	}
+}
+
+func TestOMDBLookupCheck(t *testing.T) {
+	byID := OMDBLookup{ImdbID: "tt0133093"}
+	byTitle := OMDBLookup{Title: "The Matrix", Year: "1999"}
+
+	for _, tc := range []struct {
+		name    string
+		lookup  OMDBLookup
+		movie   OMDBMovie
+		noMatch bool
+		fails   bool
+	}{
+		{"found by ID", byID, OMDBMovie{Response: "True", ImdbID: "tt0133093"}, false, false},
+		{"incorrect ID", byID, OMDBMovie{Response: "False", Error: "Incorrect IMDb ID."}, true, true},
+		{"not found", byTitle, OMDBMovie{Response: "False", Error: "Movie not found!"}, true, true},
+		{"invalid key", byID, OMDBMovie{Response: "False", Error: "Invalid API key!"}, false, true},
+		{"found by title", byTitle, OMDBMovie{Response: "True", Title: "The Matrix", Year: "1999"}, false, false},
+		{"other title", byTitle, OMDBMovie{Response: "True", Title: "The Matrix Reloaded", Year: "1999"}, true, true},
+		{"other year", byTitle, OMDBMovie{Response: "True", Title: "The Matrix", Year: "2003"}, true, true},
+	} {
+		err := tc.lookup.check(tc.movie)
+		if (err != nil) != tc.fails {
+			t.Errorf("%s: error %v", tc.name, err)
+		}
+		if errors.Is(err, errNoOMDBMatch) != tc.noMatch {
+			t.Errorf("%s: no match %v", tc.name, err)
+		}
+	}
+}
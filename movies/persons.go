@@ -2,56 +2,49 @@ package movies
 
 import (
 	"bufio"
-	"bytes"
-	"compress/gzip"
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/url"
+	"slices"
+	"strconv"
 	"text/template"
 	"time"
 
 	"github.com/nbd-wtf/go-nostr"
-	"github.com/nbd-wtf/go-nostr/nip54"
 
 	"fiatjaf/wiki-importer/common"
 )
 
 type PersonsParams struct {
 	Start        uint64
+	Export       ExportSource
 	Pool         *nostr.SimplePool
 	Logger       *log.Logger
 	PersonParsed *template.Template
 	TmdbApiKey   string
 	TmdbNostrKey string
 	TmdbRelay    string
+	Identifiers  *identifierIndex
+	// where movies and series were published, for the filmography links
+	Movies *identifierIndex
+	Series *identifierIndex
 }
 
-func persons(ctx context.Context, params PersonsParams) {
+func persons(ctx context.Context, params PersonsParams) error {
 	start := params.Start
-	pool := params.Pool
 	logger := params.Logger
-	personParsed := params.PersonParsed
 	tmdbApiKey := params.TmdbApiKey
-	tmdbNostrKey := params.TmdbNostrKey
-	tmdbRelay := params.TmdbRelay
 
-	resp, err := common.HttpGet(getYesterdays(TMDB_PERSONS))
+	export, err := params.Export.open(TMDB_PERSONS)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	defer resp.Body.Close()
-
-	gr, err := gzip.NewReader(resp.Body)
-	if err != nil {
-		panic(err)
-	}
-	defer gr.Close()
+	defer export.Close()
 
 	i := uint64(0)
-	scanner := bufio.NewScanner(gr)
+	scanner := bufio.NewScanner(export)
 	for scanner.Scan() {
 		if i < start {
 			i++
@@ -67,112 +60,154 @@ func persons(ctx context.Context, params PersonsParams) {
 			continue
 		}
 
-		searchURL := fmt.Sprintf(
-			"https://api.themoviedb.org/3/search/person?query=%s&api_key=%s",
-			url.QueryEscape(person.Name),
-			tmdbApiKey,
-		)
+		logger.Printf("Fetching TMDB person - index: %d, ID: %d, name: %s\n", i, person.ID, person.Name)
 
-		logger.Printf("Fetching TMDB person - index: %d, name: %s\n", i, person.Name)
-
-		resp, err := common.HttpGet(searchURL)
+		person, err := fetchTMDBPerson(person.ID, tmdbApiKey)
 		if err != nil {
 			logger.Printf("Error fetching TMDB person - index: %d, %v\n", i, err)
 
+			// Add a small delay to respect rate limits
+			time.Sleep(1 * time.Second)
+
 			continue
 		}
 
-		if resp.StatusCode != 200 {
-			logger.Printf(
-				"Error fetching TMDB person - index: %d, status code: %d\n",
-				i,
-				resp.StatusCode,
-			)
+		if err := publishPerson(ctx, params, person); err != nil {
+			logger.Printf("Error publishing TMDB person - index: %d, %v\n", i, err)
+		} else {
+			logger.Printf("Processed TMDB person - ID: %d, name: %s, index: %d\n", person.ID, person.Name, i)
+		}
 
-			resp.Body.Close()
+		// Add a small delay between requests to respect rate limits
+		time.Sleep(1 * time.Second)
+	}
 
-			// Add a small delay to respect rate limits
-			time.Sleep(1 * time.Second)
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read persons export: %w", err)
+	}
+
+	return nil
+}
+
+// publishPerson publishes a person under the identifier the index gives it,
+// along with everybody who had to move away from the same name and the
+// disambiguation page for that name
+func publishPerson(ctx context.Context, params PersonsParams, person TMDBPerson) error {
+	assigned, err := params.Identifiers.assign(
+		person.ID,
+		person.Name,
+		yearOf(person.Birthday),
+		person.KnownForDepartment,
+	)
+	if err != nil {
+		return err
+	}
+
+	content, err := renderPerson(params.PersonParsed, person, params.Movies, params.Series)
+	if err != nil {
+		return err
+	}
+
+	if err := publish(ctx, publishParams{
+		Pool:       params.Pool,
+		RelayURL:   params.TmdbRelay,
+		NostrKey:   params.TmdbNostrKey,
+		Title:      person.Name,
+		Identifier: assigned.Identifier,
+		Content:    content,
+	}); err != nil {
+		return err
+	}
+
+	for _, id := range assigned.movedIDs() {
+		moved, err := fetchTMDBPerson(id, params.TmdbApiKey)
+		if err != nil {
+			params.Logger.Printf("Error fetching moved TMDB person - ID: %d, %v\n", id, err)
 
 			continue
 		}
 
-		var apiResult TMDBPersonApiResult
-		if err := json.NewDecoder(resp.Body).Decode(&apiResult); err != nil {
-			body, _ := io.ReadAll(resp.Body)
-			logger.Printf("Error decoding TMDB person - index: %d, %v\n", i, err)
-			logger.Printf("Response body: %s\n", string(body))
-			resp.Body.Close()
+		if err := publishPerson(ctx, params, moved); err != nil {
+			params.Logger.Printf("Error publishing moved TMDB person - ID: %d, %v\n", id, err)
+		}
+	}
 
-			continue
+	if page := assigned.disambiguation(person.Name); page != nil {
+		if err := publishDisambiguation(ctx, params.Pool, params.TmdbRelay, params.TmdbNostrKey, page); err != nil {
+			return fmt.Errorf("publish person disambiguation: %w", err)
 		}
-		resp.Body.Close()
+	}
 
-		if len(apiResult.Results) == 0 {
-			logger.Printf("No results found for TMDB person - index: %d, name: %s\n", i, person.Name)
+	return nil
+}
 
-			continue
+func renderPerson(personParsed *template.Template, person TMDBPerson, movies *identifierIndex, series *identifierIndex) (string, error) {
+	person.Filmography = filmography(person, movies, series)
+
+	// notable works link where the filmography does
+	person.KnownFor = slices.Clone(person.KnownFor)
+	for i, work := range person.KnownFor {
+		if work.MediaType == "tv" {
+			person.KnownFor[i].Identifier = indexedIdentifier(series, work.ID, work.Title)
+		} else {
+			person.KnownFor[i].Identifier = indexedIdentifier(movies, work.ID, work.Title)
 		}
+	}
+
+	return execute(personParsed, person)
+}
+
+func fetchTMDBPerson(id int, tmdbApiKey string) (TMDBPerson, error) {
+	var person TMDBPerson
+
+	resp, err := common.HttpGet(
+		fmt.Sprintf("%s/person/%d?append_to_response=combined_credits,external_ids&api_key=%s", tmdbAPI, id, tmdbApiKey),
+	)
+	if err != nil {
+		return person, fmt.Errorf("fetch TMDB person: %w", err)
+	}
+	defer resp.Body.Close()
 
-		// Process each result
-		for resultIndex, result := range apiResult.Results {
-			logger.Printf(
-				"Found match for TMDB person - ID: %d, Name: %s, index %d, result index: %d\n",
-				result.ID,
-				result.Name,
-				i,
-				resultIndex,
-			)
-
-			content := &bytes.Buffer{}
-			if err := personParsed.Execute(content, result); err != nil {
-				logger.Printf(
-					"Error executing TMDB template - index: %d, result index: %d, %v\n",
-					i,
-					resultIndex,
-					err,
-				)
-
-				continue
-			}
-
-			normalizedIdentifier := nip54.NormalizeIdentifier(result.Name)
-
-			evt := nostr.Event{
-				CreatedAt: nostr.Now(),
-				Kind:      30818,
-				Tags: nostr.Tags{
-					{"title", result.Name},
-					{"d", normalizedIdentifier},
-				},
-				Content: content.String(),
-			}
-
-			evt.Sign(tmdbNostrKey)
-
-			relay, err := pool.EnsureRelay(tmdbRelay)
-			if err != nil {
-				logger.Printf(
-					"Error ensuring TMDB relay - index: %d, result index: %d, %v\n",
-					i,
-					resultIndex,
-					err,
-				)
-
-				continue
-			}
-
-			if err := relay.Publish(ctx, evt); err != nil {
-				logger.Printf(
-					"Error publishing TMDB person - index: %d, result index: %d, %v\n",
-					i,
-					resultIndex,
-					err,
-				)
-			}
+	if resp.StatusCode != 200 {
+		return person, fmt.Errorf("fetch TMDB person: status code %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&person); err != nil {
+		return person, fmt.Errorf("decode TMDB person: %w", err)
+	}
+
+	if person.ImdbID == "" {
+		person.ImdbID = person.ExternalIDs.ImdbID
+	}
+	person.KnownFor = knownFor(person, 4)
+
+	return person, nil
+}
+
+// knownFor picks the most popular titles a person has worked on
+func knownFor(person TMDBPerson, limit int) []KnownFor {
+	credits := append(
+		slices.Clone(person.CombinedCredits.Cast),
+		person.CombinedCredits.Crew...,
+	)
+	slices.SortStableFunc(credits, func(a, b TMDBPersonCredit) int {
+		return cmp.Compare(b.Popularity, a.Popularity)
+	})
+
+	var result []KnownFor
+	seen := make(map[string]bool)
+	for _, credit := range credits {
+		title := firstOf(credit.Title, credit.Name)
+		if title == "" || credit.Adult || seen[credit.MediaType+strconv.Itoa(credit.ID)] {
+			continue
 		}
+		seen[credit.MediaType+strconv.Itoa(credit.ID)] = true
 
-		// Add a small delay between requests to respect rate limits
-		time.Sleep(1 * time.Second)
+		result = append(result, KnownFor{ID: credit.ID, MediaType: credit.MediaType, Title: title})
+		if len(result) == limit {
+			break
+		}
 	}
+
+	return result
 }
@@ -0,0 +1,63 @@
+package movies
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// serveTMDB points tmdbAPI at a server answering each path with a body
+func serveTMDB(t *testing.T, responses map[string]string) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path+"?"+r.URL.Query().Get("append_to_response")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	previous := tmdbAPI
+	tmdbAPI = server.URL
+	t.Cleanup(func() { tmdbAPI = previous })
+}
+
+const oliverJSON = `{
+	"id": 3359,
+	"name": "Laurence Olivier",
+	"known_for_department": "Acting",
+	"combined_credits": {
+		"cast": [
+			{"id": 1, "media_type": "movie", "title": "Hamlet", "release_date": "1948-05-04", "character": "Hamlet", "popularity": 9},
+			{"id": 2, "media_type": "movie", "title": "Rebecca", "release_date": "1940-03-27", "character": "Maxim", "popularity": 12}
+		],
+		"crew": [
+			{"id": 1, "media_type": "movie", "title": "Hamlet", "release_date": "1948-05-04", "department": "Directing", "job": "Director", "popularity": 9}
+		]
+	},
+	"external_ids": {"imdb_id": "nm0000059"}
+}`
+
+func TestFetchTMDBPerson(t *testing.T) {
+	serveTMDB(t, map[string]string{
+		"/person/3359?combined_credits,external_ids": oliverJSON,
+	})
+
+	person, err := fetchTMDBPerson(3359, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(person.CombinedCredits.Cast) != 2 || len(person.CombinedCredits.Crew) != 1 {
+		t.Errorf("credits = %+v", person.CombinedCredits)
+	}
+	if person.ImdbID != "nm0000059" {
+		t.Errorf("IMDB ID = %q", person.ImdbID)
+	}
+	if len(person.KnownFor) != 2 || person.KnownFor[0].Title != "Rebecca" {
+		t.Errorf("known for = %+v", person.KnownFor)
+	}
+}
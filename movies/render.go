@@ -0,0 +1,91 @@
+package movies
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/template"
+)
+
+type RenderParams struct {
+	ID        int
+	Person    bool
+	CastLimit uint64
+	// empty for the English article without localization
+	Language string
+	// only one of these is set, depending on what is being rendered
+	TmdbParsed   *template.Template
+	MergedParsed *template.Template
+	PersonParsed *template.Template
+	TmdbApiKey   string
+	OmdbApiKey   string
+	// where filmographies link to, may be nil
+	Movies *identifierIndex
+	Series *identifierIndex
+}
+
+// render writes the article for a TMDB ID the way it would be published,
+// for checking templates
+func render(w io.Writer, params RenderParams) error {
+	var content string
+
+	switch {
+	case params.Person:
+		person, err := fetchTMDBPerson(params.ID, params.TmdbApiKey)
+		if err != nil {
+			return err
+		}
+
+		content, err = renderPerson(params.PersonParsed, person, params.Movies, params.Series)
+		if err != nil {
+			return err
+		}
+
+	case params.MergedParsed != nil:
+		tmpl, err := localizedTemplate(params.MergedParsed, params.Language)
+		if err != nil {
+			return err
+		}
+
+		tmdbMovie, err := fetchTMDBMovie(params.ID, params.TmdbApiKey)
+		if err != nil {
+			return err
+		}
+
+		omdbMovie, err := fetchOMDBMovie(tmdbMovie.omdbLookup(), params.OmdbApiKey)
+		if err != nil && !errors.Is(err, errNoOMDBMatch) {
+			return err
+		}
+
+		movie := mergeMovies(tmdbMovie.localized(params.Language), omdbMovie)
+
+		content, err = renderMergedMovie(tmpl, movie, params.CastLimit)
+		if err != nil {
+			return err
+		}
+
+	default:
+		tmpl, err := localizedTemplate(params.TmdbParsed, params.Language)
+		if err != nil {
+			return err
+		}
+
+		movie, err := fetchTMDBMovie(params.ID, params.TmdbApiKey)
+		if err != nil {
+			return err
+		}
+
+		movie = movie.localized(params.Language)
+
+		content, err = renderTMDBMovie(tmpl, &movie, params.CastLimit)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprintln(w, content); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,248 @@
+package movies
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"fiatjaf/wiki-importer/common"
+)
+
+// TMDB doesn't take change intervals longer than this
+const changesMaxDays = 14
+
+var errSkipped = errors.New("skipped by content policy")
+
+// ContentPolicy decides which TMDB entries are left out
+type ContentPolicy struct {
+	SkipAdult bool
+	SkipVideo bool
+}
+
+func (p ContentPolicy) skips(adult bool, video bool) bool {
+	return (adult && p.SkipAdult) || (video && p.SkipVideo)
+}
+
+type SyncParams struct {
+	// zero to continue from the last successful run
+	Since   time.Time
+	Policy  ContentPolicy
+	Movies  MoviesParams
+	Persons PersonsParams
+}
+
+// syncState is what we remember between sync runs: when the last one was
+// and what failed in it, to be tried again in the next one
+type syncState struct {
+	LastRun       time.Time `json:"last_run"`
+	FailedMovies  []int     `json:"failed_movies,omitempty"`
+	FailedPersons []int     `json:"failed_persons,omitempty"`
+}
+
+type changesPage struct {
+	Results []struct {
+		ID    int   `json:"id"`
+		Adult *bool `json:"adult"`
+	} `json:"results"`
+	Page       int `json:"page"`
+	TotalPages int `json:"total_pages"`
+}
+
+// runChangesSync republishes the movies and the persons TMDB says have
+// changed since the last run, along with the ones that failed in it. Persons
+// go last so their filmographies link to the identifiers movies have just
+// got.
+func runChangesSync(ctx context.Context, params SyncParams) error {
+	logger := params.Movies.Logger
+	now := time.Now()
+
+	state, err := loadSyncState()
+	if err != nil {
+		return err
+	}
+
+	since := params.Since
+	if since.IsZero() {
+		since = state.LastRun
+	}
+	if since.IsZero() {
+		since = now.AddDate(0, 0, -1)
+	}
+
+	logger.Printf("Syncing changes since %s\n", since.Format(time.DateOnly))
+
+	movieIDs, err := fetchChanges("movie", since, now, params.Movies.TmdbApiKey, params.Policy)
+	if err != nil {
+		return err
+	}
+
+	logger.Printf("Found %d changed movies\n", len(movieIDs))
+	movieIDs = withRetries(state.FailedMovies, movieIDs)
+
+	var failedMovies []int
+	params.Movies.Policy = params.Policy
+	for i, id := range movieIDs {
+		if err := processMovie(ctx, params.Movies, uint64(i), []byte(fmt.Sprintf(`{"id":%d}`, id))); err != nil {
+			failedMovies = append(failedMovies, id)
+		}
+	}
+
+	personIDs, err := fetchChanges("person", since, now, params.Persons.TmdbApiKey, params.Policy)
+	if err != nil {
+		return err
+	}
+
+	logger.Printf("Found %d changed persons\n", len(personIDs))
+	personIDs = withRetries(state.FailedPersons, personIDs)
+
+	var failedPersons []int
+	for i, id := range personIDs {
+		person, err := fetchTMDBPerson(id, params.Persons.TmdbApiKey)
+		if err != nil {
+			logger.Printf("Error fetching TMDB person - index: %d, ID: %d, %v\n", i, id, err)
+			failedPersons = append(failedPersons, id)
+
+			continue
+		}
+
+		if params.Policy.skips(person.Adult, false) {
+			logger.Printf("Skipped TMDB person - index: %d, ID: %d, %v\n", i, id, errSkipped)
+
+			continue
+		}
+
+		if err := publishPerson(ctx, params.Persons, person); err != nil {
+			logger.Printf("Error publishing TMDB person - index: %d, ID: %d, %v\n", i, id, err)
+			failedPersons = append(failedPersons, id)
+
+			continue
+		}
+
+		logger.Printf("Processed TMDB person - ID: %d, name: %s, index: %d\n", person.ID, person.Name, i)
+	}
+
+	if len(failedMovies) > 0 || len(failedPersons) > 0 {
+		logger.Printf("%d movies and %d persons failed, they will be tried again in the next run\n", len(failedMovies), len(failedPersons))
+	}
+
+	state.LastRun = now
+	state.FailedMovies = failedMovies
+	state.FailedPersons = failedPersons
+
+	return saveSyncState(state)
+}
+
+// withRetries puts the IDs that failed last time before the changed ones,
+// each only once
+func withRetries(failed []int, changed []int) []int {
+	ids := slices.Clone(failed)
+	for _, id := range changed {
+		if !slices.Contains(failed, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// fetchChanges lists the IDs of the movies or persons changed between two
+// dates, leaving out adult entries if the policy says so
+func fetchChanges(kind string, since time.Time, until time.Time, tmdbApiKey string, policy ContentPolicy) ([]int, error) {
+	var ids []int
+	seen := make(map[int]bool)
+
+	for start := since; start.Before(until); start = start.AddDate(0, 0, changesMaxDays) {
+		end := start.AddDate(0, 0, changesMaxDays)
+		if end.After(until) {
+			end = until
+		}
+
+		for page, totalPages := 1, 1; page <= totalPages; page++ {
+			resp, err := common.HttpGet(
+				fmt.Sprintf(
+					"%s/%s/changes?start_date=%s&end_date=%s&page=%d&api_key=%s",
+					tmdbAPI,
+					kind,
+					start.Format(time.DateOnly),
+					end.Format(time.DateOnly),
+					page,
+					tmdbApiKey,
+				),
+			)
+			if err != nil {
+				return nil, fmt.Errorf("fetch TMDB %s changes: %w", kind, err)
+			}
+
+			if resp.StatusCode != 200 {
+				resp.Body.Close()
+				return nil, fmt.Errorf("fetch TMDB %s changes: status code %d", kind, resp.StatusCode)
+			}
+
+			var changes changesPage
+			err = json.NewDecoder(resp.Body).Decode(&changes)
+			resp.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("decode TMDB %s changes: %w", kind, err)
+			}
+
+			for _, result := range changes.Results {
+				adult := result.Adult != nil && *result.Adult
+				if seen[result.ID] || policy.skips(adult, false) {
+					continue
+				}
+
+				seen[result.ID] = true
+				ids = append(ids, result.ID)
+			}
+
+			totalPages = changes.TotalPages
+		}
+	}
+
+	return ids, nil
+}
+
+func loadSyncState() (syncState, error) {
+	var state syncState
+
+	path, err := common.StatePath("movies-sync.json")
+	if err != nil {
+		return state, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("read sync state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("decode sync state: %w", err)
+	}
+
+	return state, nil
+}
+
+func saveSyncState(state syncState) error {
+	path, err := common.StatePath("movies-sync.json")
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("write sync state: %w", err)
+	}
+
+	return nil
+}
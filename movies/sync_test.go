@@ -0,0 +1,83 @@
+package movies
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestContentPolicy(t *testing.T) {
+	policy := ContentPolicy{SkipAdult: true}
+
+	if !policy.skips(true, false) {
+		t.Error("adult entry not skipped")
+	}
+	if policy.skips(false, true) {
+		t.Error("video entry skipped without SkipVideo")
+	}
+	if (ContentPolicy{}).skips(true, true) {
+		t.Error("empty policy skipped something")
+	}
+}
+
+func TestSyncState(t *testing.T) {
+	chdirTemp(t)
+
+	state, err := loadSyncState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.LastRun.IsZero() {
+		t.Fatalf("first run has a last run: %v", state.LastRun)
+	}
+
+	state.LastRun = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	state.FailedMovies = []int{603}
+	state.FailedPersons = []int{3359}
+	if err := saveSyncState(state); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadSyncState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.LastRun.Equal(state.LastRun) {
+		t.Errorf("last run = %v, want %v", loaded.LastRun, state.LastRun)
+	}
+	if !slices.Equal(loaded.FailedMovies, state.FailedMovies) || !slices.Equal(loaded.FailedPersons, state.FailedPersons) {
+		t.Errorf("failed = %v %v, want %v %v", loaded.FailedMovies, loaded.FailedPersons, state.FailedMovies, state.FailedPersons)
+	}
+}
+
+func TestWithRetries(t *testing.T) {
+	for _, test := range []struct {
+		failed   []int
+		changed  []int
+		expected []int
+	}{
+		{nil, []int{1, 2}, []int{1, 2}},
+		{[]int{3}, []int{1, 2}, []int{3, 1, 2}},
+		{[]int{2}, []int{1, 2}, []int{2, 1}},
+		{[]int{4}, nil, []int{4}},
+	} {
+		if got := withRetries(test.failed, test.changed); !slices.Equal(got, test.expected) {
+			t.Errorf("withRetries(%v, %v) = %v, want %v", test.failed, test.changed, got, test.expected)
+		}
+	}
+}
+
+func TestFetchChanges(t *testing.T) {
+	serveTMDB(t, map[string]string{
+		"/movie/changes?": `{"results": [{"id": 603, "adult": false}, {"id": 99, "adult": true}, {"id": 603}], "page": 1, "total_pages": 1}`,
+	})
+
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	ids, err := fetchChanges("movie", since, since.AddDate(0, 0, 1), "key", ContentPolicy{SkipAdult: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(ids, []int{603}) {
+		t.Errorf("changes = %v, want [603]", ids)
+	}
+}
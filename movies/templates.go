@@ -0,0 +1,109 @@
+package movies
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateFuncs are available to the embedded templates and to overrides
+var templateFuncs = template.FuncMap{
+	// t "Plot" translates template strings for localized articles
+	"t": func(s string) string {
+		return s
+	},
+	// join .Genres ", "
+	"join": func(items []string, sep string) string {
+		return strings.Join(items, sep)
+	},
+	// year "2001-09-11" is 2001
+	"year": yearOf,
+	// wikilink "Hamlet" or wikilink "hamlet-1948" "Hamlet"
+	"wikilink": func(target string, label ...string) string {
+		if len(label) > 0 && label[0] != "" && label[0] != target {
+			return "[[" + target + "|" + label[0] + "]]"
+		}
+		return "[[" + target + "]]"
+	},
+	// wikilinks .Directors ", " links each name
+	"wikilinks": func(targets []string, sep string) string {
+		links := make([]string, len(targets))
+		for i, target := range targets {
+			links[i] = "[[" + target + "]]"
+		}
+		return strings.Join(links, sep)
+	},
+	// money 150000000 is $150,000,000
+	"money": func(amount int) string {
+		return "$" + thousands(amount)
+	},
+	// thousands 1234567 is 1,234,567
+	"thousands": thousands,
+	// runtime 136 is 2h 16min
+	"runtime": formatRuntime,
+	// number 1234.5678 is 1,234.6
+	"number": formatNumber,
+	// date "January 2, 2006" .Birthday, values that aren't ISO dates are
+	// returned as they are
+	"date": func(layout string, value any) string {
+		s, _ := value.(string)
+		t, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			return s
+		}
+		return t.Format(layout)
+	},
+}
+
+// loadTemplate parses a template from the overrides directory when it has
+// one with that name, or the embedded one otherwise
+func loadTemplate(dir string, name string) (*template.Template, error) {
+	var fsys fs.FS = templates
+
+	if dir != "" {
+		overrides := os.DirFS(dir)
+		if _, err := fs.Stat(overrides, name); err == nil {
+			fsys = overrides
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("template %s: %w", name, err)
+		}
+	}
+
+	tmpl, err := template.New(name).Funcs(templateFuncs).ParseFS(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("parse template %s: %w", name, err)
+	}
+
+	return tmpl, nil
+}
+
+func execute(tmpl *template.Template, data any) (string, error) {
+	content := &bytes.Buffer{}
+	if err := tmpl.Execute(content, data); err != nil {
+		return "", fmt.Errorf("execute template %s: %w", tmpl.Name(), err)
+	}
+
+	return content.String(), nil
+}
+
+// thousands formats 1234567 as 1,234,567
+func thousands(n int) string {
+	s := strconv.Itoa(n)
+
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+
+	return sign + s
+}
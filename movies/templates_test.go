@@ -0,0 +1,148 @@
+package movies
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	tmpl, err := loadTemplate("", "tmdb.adoc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		text     string
+		data     any
+		expected string
+	}{
+		{`{{join . ", "}}`, []string{"Drama", "War"}, "Drama, War"},
+		{`{{year .}}`, "1948-05-04", "1948"},
+		{`{{wikilink .}}`, "Hamlet", "[[Hamlet]]"},
+		{`{{wikilink "hamlet-1948" .}}`, "Hamlet", "[[hamlet-1948|Hamlet]]"},
+		{`{{wikilinks . " and "}}`, []string{"A", "B"}, "[[A]] and [[B]]"},
+		{`{{money .}}`, 150000000, "$150,000,000"},
+		{`{{money .}}`, 999, "$999"},
+		{`{{date "January 2, 2006" .}}`, "1907-05-22", "May 22, 1907"},
+		{`{{date "January 2, 2006" .}}`, nil, ""},
+		{`{{date "2006" .}}`, "sometime", "sometime"},
+	}
+
+	for _, tt := range tests {
+		test, err := tmpl.New("test").Parse(tt.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := execute(test, tt.data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.text, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("%s = %q, want %q", tt.text, got, tt.expected)
+		}
+	}
+}
+
+func TestLoadTemplate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "tmdb.adoc"), []byte("{{.Title}} ({{year .ReleaseDate}})"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	movie := TMDBMovie{Title: "Hamlet", ReleaseDate: "1948-05-04"}
+
+	override, err := loadTemplate(dir, "tmdb.adoc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := execute(override, movie); got != "Hamlet (1948)" {
+		t.Errorf("override rendered %q", got)
+	}
+
+	// not in the overrides directory, so the embedded one is used
+	embedded, err := loadTemplate(dir, "person.adoc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := execute(embedded, TMDBPerson{Name: "Laurence Olivier", Birthday: "1907-05-22"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "Born:: May 22, 1907") {
+		t.Errorf("embedded person template rendered:\n%s", got)
+	}
+}
+
+func TestEmbeddedTemplates(t *testing.T) {
+	for name, data := range map[string]any{
+		"tmdb.adoc":       TMDBArticle{TMDBMovie: &TMDBMovie{Title: "Hamlet", Budget: 2000000}},
+		"omdb.adoc":       normalizeOMDBMovie(OMDBMovie{Title: "Hamlet", Runtime: "N/A"}),
+		"merged.adoc":     MergedMovie{Title: "Hamlet", Directors: []string{"Laurence Olivier"}},
+		"person.adoc":     TMDBPerson{Name: "Laurence Olivier"},
+		"tv.adoc":         tvArticle{},
+		"season.adoc":     seasonArticle{},
+		"collection.adoc": collectionArticle{},
+		"company.adoc":    companyArticle{},
+		"genre.adoc":      genreArticle{},
+	} {
+		tmpl, err := loadTemplate("", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := execute(tmpl, data); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestEmbeddedTemplateText(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     any
+		expected string
+		absent   string
+	}{
+		{
+			name:     "omdb.adoc",
+			data:     normalizeOMDBMovie(OMDBMovie{Title: "Rope", Year: "1948", Type: "movie", Writer: "Arthur Laurents", Director: "N/A"}),
+			expected: "Rope is a 1948 movie written by Arthur Laurents.",
+		},
+		{
+			name:     "omdb.adoc",
+			data:     normalizeOMDBMovie(OMDBMovie{Title: "Rope", Year: "1948", Type: "movie", Writer: "Arthur Laurents", Director: "Alfred Hitchcock"}),
+			expected: "Rope is a 1948 movie written by Arthur Laurents and directed by Alfred Hitchcock.",
+		},
+		{
+			name:     "person.adoc",
+			data:     TMDBPerson{Name: "Laurence Olivier", Popularity: 12.3456},
+			expected: "Popularity:: 12.3",
+			absent:   "12.3456",
+		},
+		{
+			name:     "tv.adoc",
+			data:     tvArticle{TMDBSeries: TMDBSeries{Name: "The Office", Popularity: 98.7654}},
+			expected: "Popularity:: 98.8",
+			absent:   "98.7654",
+		},
+	}
+
+	for _, tt := range tests {
+		tmpl, err := loadTemplate("", tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := execute(tmpl, tt.data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !strings.Contains(got, tt.expected) || (tt.absent != "" && strings.Contains(got, tt.absent)) {
+			t.Errorf("%s should have %q and not %q:\n%s", tt.name, tt.expected, tt.absent, got)
+		}
+	}
+}
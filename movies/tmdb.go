@@ -1,24 +1,29 @@
 package movies
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"log"
-	"strings"
+	"slices"
 	"text/template"
 
 	"fiatjaf/wiki-importer/common"
 
 	"github.com/nbd-wtf/go-nostr"
-	"github.com/nbd-wtf/go-nostr/nip54"
 )
 
 type TMDBResult struct {
 	TMDBId               int
 	IMDBId               string
 	NormalizedIdentifier string
+	// the untranslated title and the release year, to find the movie on
+	// OMDB when TMDB has no IMDB ID for it
+	Title string
+	Year  string
+	// movies that lost the bare identifier to this one and need republishing
+	Moved          []int
+	Disambiguation *disambiguation
 }
 
 type TmdbParams struct {
@@ -30,6 +35,11 @@ type TmdbParams struct {
 	TmdbRelay    string
 	Pool         *nostr.SimplePool
 	TmdbParsed   *template.Template
+	Identifiers  *identifierIndex
+	CastLimit    uint64
+	Policy       ContentPolicy
+	// nil to publish only in English
+	Localization *Localization
 }
 
 func NewTmdbParams(
@@ -41,6 +51,10 @@ func NewTmdbParams(
 	tmdbRelay string,
 	pool *nostr.SimplePool,
 	tmdbParsed *template.Template,
+	identifiers *identifierIndex,
+	castLimit uint64,
+	policy ContentPolicy,
+	localization *Localization,
 ) TmdbParams {
 	return TmdbParams{
 		Index:        index,
@@ -51,6 +65,10 @@ func NewTmdbParams(
 		TmdbRelay:    tmdbRelay,
 		Pool:         pool,
 		TmdbParsed:   tmdbParsed,
+		Identifiers:  identifiers,
+		CastLimit:    castLimit,
+		Policy:       policy,
+		Localization: localization,
 	}
 }
 
@@ -59,10 +77,6 @@ func tmdb(ctx context.Context, params TmdbParams) (TMDBResult, error) {
 	line := params.Line
 	logger := params.Logger
 	tmdbApiKey := params.TmdbApiKey
-	tmdbNostrKey := params.TmdbNostrKey
-	tmdbRelay := params.TmdbRelay
-	pool := params.Pool
-	tmdbParsed := params.TmdbParsed
 
 	empty := TMDBResult{}
 
@@ -71,88 +85,159 @@ func tmdb(ctx context.Context, params TmdbParams) (TMDBResult, error) {
 		return empty, fmt.Errorf("unmarshal TMDB movie - index: %d, %w", index, err)
 	}
 
-	{
-		logger.Printf("Processing TMDB movie - ID: %d, index: %d\n", movie.ID, index)
+	logger.Printf("Processing TMDB movie - ID: %d, index: %d\n", movie.ID, index)
+
+	movie, err := fetchTMDBMovie(movie.ID, tmdbApiKey)
+	if err != nil {
+		return empty, err
+	}
+
+	if params.Policy.skips(movie.Adult, movie.Video) {
+		return empty, fmt.Errorf("TMDB movie %d: %w", movie.ID, errSkipped)
+	}
+
+	var result TMDBResult
+
+	for _, language := range params.Localization.languages() {
+		localized, err := publishTMDBMovie(ctx, params, movie, language)
+		if err != nil {
+			return result, fmt.Errorf("TMDB movie - language: %s, index: %d, %w", language, index, err)
+		}
+
+		moved := result.Moved
+		if params.Localization.isPrimary(language) {
+			result = localized
+		}
+		for _, id := range append(moved, localized.Moved...) {
+			if !slices.Contains(result.Moved, id) {
+				result.Moved = append(result.Moved, id)
+			}
+		}
+	}
+
+	result.Title = movie.Title
+	result.Year = yearOf(movie.ReleaseDate)
+
+	return result, nil
+}
+
+// publishTMDBMovie publishes the article for a movie in one language, and
+// the disambiguation page when its title is shared
+func publishTMDBMovie(ctx context.Context, params TmdbParams, movie TMDBMovie, language string) (TMDBResult, error) {
+	empty := TMDBResult{}
+
+	tmpl, err := localizedTemplate(params.TmdbParsed, language)
+	if err != nil {
+		return empty, err
+	}
+
+	english := movie.Title
+	movie = movie.localized(language)
+
+	content, err := renderTMDBMovie(tmpl, &movie, params.CastLimit)
+	if err != nil {
+		return empty, err
+	}
+
+	indexTitle := params.Localization.indexTitle(language, english, movie.Title)
+	identifiers := params.Localization.index(language, params.Identifiers)
+	assigned, err := identifiers.assign(
+		movie.ID,
+		indexTitle,
+		params.Localization.qualifiers(language, yearOf(movie.ReleaseDate))...,
+	)
+	if err != nil {
+		return empty, err
+	}
+
+	if err := publish(ctx, publishParams{
+		Pool:       params.Pool,
+		RelayURL:   params.TmdbRelay,
+		NostrKey:   params.TmdbNostrKey,
+		Title:      movie.Title,
+		Identifier: assigned.Identifier,
+		Content:    content,
+		Language:   language,
+	}); err != nil {
+		return empty, fmt.Errorf("publish TMDB movie: %w", err)
+	}
+
+	result := TMDBResult{
+		TMDBId:               movie.ID,
+		IMDBId:               movie.ImdbID,
+		NormalizedIdentifier: assigned.Identifier,
+		Moved:                assigned.movedIDs(),
+		Disambiguation:       assigned.disambiguation(indexTitle),
+	}
+
+	if result.Disambiguation != nil {
+		result.Disambiguation.Language = language
+
+		if err := publishDisambiguation(ctx, params.Pool, params.TmdbRelay, params.TmdbNostrKey, result.Disambiguation); err != nil {
+			return result, fmt.Errorf("publish TMDB disambiguation: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// renderTMDBMovie writes the TMDB article for a movie
+func renderTMDBMovie(tmdbParsed *template.Template, movie *TMDBMovie, castLimit uint64) (string, error) {
+	movie.ReleaseDate = isoDate(movie.ReleaseDate)
+	movie.limitCast(castLimit)
+
+	return execute(tmdbParsed, TMDBArticle{
+		TMDBMovie: movie,
+		Ratings:   tmdbRatings(*movie),
+	})
+}
 
+// fetchTMDBMovie gets the details, the translations and the credits of a movie
+func fetchTMDBMovie(id int, tmdbApiKey string) (TMDBMovie, error) {
+	var movie TMDBMovie
+
+	{
 		// basic movie data
 		resp, err := common.HttpGet(
 			fmt.Sprintf(
-				"https://api.themoviedb.org/3/movie/%d?api_key=%s",
-				movie.ID,
+				"%s/movie/%d?append_to_response=translations&api_key=%s",
+				tmdbAPI,
+				id,
 				tmdbApiKey,
 			),
 		)
 
 		if err != nil {
-			return empty, fmt.Errorf("fetch TMDB movie details: %w", err)
+			return movie, fmt.Errorf("fetch TMDB movie details: %w", err)
 		}
 		if err := json.NewDecoder(resp.Body).Decode(&movie); err != nil {
 			resp.Body.Close()
-			return empty, fmt.Errorf("decode TMDB movie details: %w", err)
+			return movie, fmt.Errorf("decode TMDB movie details: %w", err)
 		}
 		resp.Body.Close()
-
-		if spl := strings.Split(movie.ReleaseDate, "-"); len(spl) == 3 {
-			movie.ReleaseDate = spl[0]
-		}
 	}
 
 	{
-		// cast
+		// cast and crew
 		resp, err := common.HttpGet(
 			fmt.Sprintf(
-				"https://api.themoviedb.org/3/movie/%d/credits?api_key=%s",
-				movie.ID,
+				"%s/movie/%d/credits?api_key=%s",
+				tmdbAPI,
+				id,
 				tmdbApiKey,
 			),
 		)
 
 		if err != nil {
-			return empty, fmt.Errorf("fetch TMDB movie credits: %w", err)
+			return movie, fmt.Errorf("fetch TMDB movie credits: %w", err)
 		}
 
 		if err := json.NewDecoder(resp.Body).Decode(&movie); err != nil {
 			resp.Body.Close()
-			return empty, fmt.Errorf("decode TMDB movie credits: %w", err)
+			return movie, fmt.Errorf("decode TMDB movie credits: %w", err)
 		}
 		resp.Body.Close()
-
-		if len(movie.Cast) > 4 {
-			movie.Cast = movie.Cast[0:4]
-		}
-	}
-
-	content := &bytes.Buffer{}
-	if err := tmdbParsed.Execute(content, movie); err != nil {
-		return empty, fmt.Errorf("execute TMDB template: %w", err)
-	}
-
-	normalizedIdentifier := nip54.NormalizeIdentifier(movie.Title)
-
-	evt := nostr.Event{
-		CreatedAt: nostr.Now(),
-		Kind:      30818,
-		Tags: nostr.Tags{
-			{"title", movie.Title},
-			{"d", normalizedIdentifier},
-		},
-		Content: content.String(),
 	}
 
-	evt.Sign(tmdbNostrKey)
-
-	relay, err := pool.EnsureRelay(tmdbRelay)
-	if err != nil {
-		return empty, fmt.Errorf("error ensuring TMDB relay - index: %d, %w", index, err)
-	}
-
-	if err := relay.Publish(ctx, evt); err != nil {
-		return empty, fmt.Errorf("error publishing TMDB event - index: %d, %w", index, err)
-	}
-
-	return TMDBResult{
-		TMDBId:               movie.ID,
-		IMDBId:               movie.ImdbID,
-		NormalizedIdentifier: normalizedIdentifier,
-	}, nil
+	return movie, nil
 }
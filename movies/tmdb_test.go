@@ -0,0 +1,22 @@
+package movies
+
+import "testing"
+
+func TestFetchTMDBMovie(t *testing.T) {
+	serveTMDB(t, map[string]string{
+		"/movie/603?translations": `{"id": 603, "title": "The Matrix", "release_date": "1999-03-30"}`,
+		"/movie/603/credits?":     `{"id": 603, "cast": [{"name": "Keanu Reeves", "character": "Neo"}]}`,
+	})
+
+	movie, err := fetchTMDBMovie(603, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if movie.Title != "The Matrix" || len(movie.Cast) != 1 || movie.Cast[0].Character != "Neo" {
+		t.Errorf("movie = %+v", movie)
+	}
+
+	if _, err := fetchTMDBMovie(604, "key"); err == nil {
+		t.Error("missing movie fetched")
+	}
+}
@@ -0,0 +1,282 @@
+package movies
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"text/template"
+	"time"
+
+	"fiatjaf/wiki-importer/common"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+const tvCastLimit = 10
+
+type TvParams struct {
+	Start        uint64
+	Export       ExportSource
+	Pool         *nostr.SimplePool
+	Logger       *log.Logger
+	TmdbApiKey   string
+	TmdbNostrKey string
+	TmdbRelay    string
+	TvParsed     *template.Template
+	SeasonParsed *template.Template
+	Seasons      bool // also publish one article per season
+	Identifiers  *identifierIndex
+	// identifiers of the season articles, by TMDB season ID
+	SeasonIdentifiers *identifierIndex
+}
+
+type tvArticle struct {
+	TMDBSeries
+	SeasonArticles bool
+}
+
+type seasonArticle struct {
+	TMDBSeason
+	Series           string
+	SeriesIdentifier string
+}
+
+func tv(ctx context.Context, params TvParams) error {
+	start := params.Start
+	logger := params.Logger
+
+	export, err := params.Export.open(TMDB_TV)
+	if err != nil {
+		return err
+	}
+	defer export.Close()
+
+	i := uint64(0)
+	scanner := bufio.NewScanner(export)
+	for scanner.Scan() {
+		if i < start {
+			i++
+
+			continue
+		}
+
+		var series TMDBSeries
+		if err := json.Unmarshal(scanner.Bytes(), &series); err != nil {
+			logger.Printf("Error unmarshalling TMDB series - index: %d, %v\n", i, err)
+		} else {
+			logger.Printf("Processing TMDB series - ID: %d, index: %d\n", series.ID, i)
+
+			if err := publishSeries(ctx, params, series.ID); err != nil {
+				logger.Printf("Error processing TMDB series - index: %d, %v\n", i, err)
+			}
+		}
+
+		i++
+
+		// Add a small delay between requests to respect rate limits
+		time.Sleep(1 * time.Second)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read TV series export: %w", err)
+	}
+
+	return nil
+}
+
+// publishSeries publishes a series and its seasons under the identifiers the
+// indexes give them, along with every series that had to move away from the
+// same name and the disambiguation pages. Seasons are named after their
+// series, so they only move along with it and are published again with it.
+func publishSeries(ctx context.Context, params TvParams, id int) error {
+	logger := params.Logger
+
+	series, err := fetchTMDBSeries(id, params.TmdbApiKey)
+	if err != nil {
+		return err
+	}
+
+	// nothing to give an identifier to or to title the article with
+	if series.ID == 0 || series.Name == "" {
+		return fmt.Errorf("TMDB series %d has no ID or name", id)
+	}
+
+	assigned, err := params.Identifiers.assign(series.ID, series.Name, series.FirstAirDate)
+	if err != nil {
+		return err
+	}
+
+	var seasons []assignment
+	if params.Seasons {
+		for i, ref := range series.Seasons {
+			season, err := params.SeasonIdentifiers.assign(ref.ID, seasonTitle(series, ref.Name), series.FirstAirDate)
+			if err != nil {
+				return err
+			}
+
+			series.Seasons[i].Identifier = season.Identifier
+			seasons = append(seasons, season)
+		}
+	}
+
+	content, err := execute(params.TvParsed, tvArticle{
+		TMDBSeries:     series,
+		SeasonArticles: params.Seasons,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := publish(ctx, publishParams{
+		Pool:       params.Pool,
+		RelayURL:   params.TmdbRelay,
+		NostrKey:   params.TmdbNostrKey,
+		Title:      series.Name,
+		Identifier: assigned.Identifier,
+		Content:    content,
+	}); err != nil {
+		return err
+	}
+
+	logger.Printf("Published TMDB series - ID: %d, %s\n", series.ID, series.Name)
+
+	for i, seasonAssigned := range seasons {
+		season := series.Seasons[i]
+
+		// Add a small delay between requests to respect rate limits
+		time.Sleep(1 * time.Second)
+
+		if err := publishSeason(ctx, params, series, assigned.Identifier, season); err != nil {
+			logger.Printf(
+				"Error processing TMDB season - series ID: %d, season: %d, %v\n",
+				series.ID,
+				season.SeasonNumber,
+				err,
+			)
+		}
+
+		if page := seasonAssigned.disambiguation(seasonTitle(series, season.Name)); page != nil {
+			if err := publishDisambiguation(ctx, params.Pool, params.TmdbRelay, params.TmdbNostrKey, page); err != nil {
+				logger.Printf("Error publishing season disambiguation - series ID: %d, %v\n", series.ID, err)
+			}
+		}
+	}
+
+	for _, id := range assigned.movedIDs() {
+		time.Sleep(1 * time.Second)
+
+		if err := publishSeries(ctx, params, id); err != nil {
+			logger.Printf("Error publishing moved TMDB series - ID: %d, %v\n", id, err)
+		}
+	}
+
+	if page := assigned.disambiguation(series.Name); page != nil {
+		if err := publishDisambiguation(ctx, params.Pool, params.TmdbRelay, params.TmdbNostrKey, page); err != nil {
+			return fmt.Errorf("publish series disambiguation: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func publishSeason(ctx context.Context, params TvParams, series TMDBSeries, seriesIdentifier string, ref TMDBSeasonRef) error {
+	season, err := fetchTMDBSeason(series.ID, ref.SeasonNumber, params.TmdbApiKey)
+	if err != nil {
+		return err
+	}
+
+	content, err := execute(params.SeasonParsed, seasonArticle{
+		TMDBSeason:       season,
+		Series:           series.Name,
+		SeriesIdentifier: seriesIdentifier,
+	})
+	if err != nil {
+		return err
+	}
+
+	return publish(ctx, publishParams{
+		Pool:       params.Pool,
+		RelayURL:   params.TmdbRelay,
+		NostrKey:   params.TmdbNostrKey,
+		Title:      seasonTitle(series, season.Name),
+		Identifier: ref.Identifier,
+		Content:    content,
+	})
+}
+
+// seasonTitle names a season after its series, "Season 1" alone would be
+// shared by every series
+func seasonTitle(series TMDBSeries, season string) string {
+	return series.Name + " " + season
+}
+
+// fetchTMDBSeries gets a series with its cast, keeping only the years of
+// its dates
+func fetchTMDBSeries(id int, tmdbApiKey string) (TMDBSeries, error) {
+	var series TMDBSeries
+
+	resp, err := common.HttpGet(
+		fmt.Sprintf(
+			"%s/tv/%d?append_to_response=aggregate_credits,external_ids&api_key=%s",
+			tmdbAPI,
+			id,
+			tmdbApiKey,
+		),
+	)
+	if err != nil {
+		return series, fmt.Errorf("fetch TMDB series details: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return series, fmt.Errorf("fetch TMDB series details: status code %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&series); err != nil {
+		return series, fmt.Errorf("decode TMDB series details: %w", err)
+	}
+
+	if len(series.AggregateCredits.Cast) > tvCastLimit {
+		series.AggregateCredits.Cast = series.AggregateCredits.Cast[0:tvCastLimit]
+	}
+
+	series.FirstAirDate = yearOf(series.FirstAirDate)
+	series.LastAirDate = yearOf(series.LastAirDate)
+	for i := range series.Seasons {
+		series.Seasons[i].AirDate = yearOf(series.Seasons[i].AirDate)
+	}
+
+	return series, nil
+}
+
+func fetchTMDBSeason(seriesID int, seasonNumber int, tmdbApiKey string) (TMDBSeason, error) {
+	var season TMDBSeason
+
+	resp, err := common.HttpGet(
+		fmt.Sprintf(
+			"%s/tv/%d/season/%d?api_key=%s",
+			tmdbAPI,
+			seriesID,
+			seasonNumber,
+			tmdbApiKey,
+		),
+	)
+	if err != nil {
+		return season, fmt.Errorf("fetch TMDB season: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return season, fmt.Errorf("fetch TMDB season: status code %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&season); err != nil {
+		return season, fmt.Errorf("decode TMDB season: %w", err)
+	}
+
+	season.AirDate = yearOf(season.AirDate)
+
+	return season, nil
+}
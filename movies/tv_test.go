@@ -0,0 +1,206 @@
+package movies
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestFetchTMDBSeries(t *testing.T) {
+	serveTMDB(t, map[string]string{
+		"/tv/2316?aggregate_credits,external_ids": `{
+			"id": 2316, "name": "The Office", "original_name": "The Office",
+			"first_air_date": "2005-03-24", "last_air_date": "2013-05-16",
+			"seasons": [
+				{"id": 3625, "name": "Specials", "season_number": 0, "episode_count": 28, "air_date": "2007-09-27"},
+				{"id": 3626, "name": "Season 1", "season_number": 1, "episode_count": 6, "air_date": "2005-03-24"}
+			],
+			"aggregate_credits": {"cast": [
+				{"name": "A"}, {"name": "B"}, {"name": "C"}, {"name": "D"}, {"name": "E"}, {"name": "F"},
+				{"name": "G"}, {"name": "H"}, {"name": "I"}, {"name": "J"}, {"name": "K"}, {"name": "L"}
+			]},
+			"external_ids": {"imdb_id": "tt0386676"}
+		}`,
+		"/tv/2316/season/1?": `{
+			"id": 3626, "name": "Season 1", "season_number": 1, "air_date": "2005-03-24",
+			"episodes": [
+				{"episode_number": 1, "name": "Pilot", "air_date": "2005-03-24", "runtime": 23},
+				{"episode_number": 2, "name": "Diversity Day", "air_date": "2005-03-29", "overview": "Michael's off-color remark."}
+			]
+		}`,
+	})
+
+	series, err := fetchTMDBSeries(2316, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := fetchTMDBSeries(404, "key"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("missing series: %v", err)
+	}
+	if _, err := fetchTMDBSeason(2316, 9, "key"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("missing season: %v", err)
+	}
+
+	season, err := fetchTMDBSeason(2316, 1, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		got      any
+		expected any
+	}{
+		{"cast limit", len(series.AggregateCredits.Cast), tvCastLimit},
+		{"first aired", series.FirstAirDate, "2005"},
+		{"last aired", series.LastAirDate, "2013"},
+		{"season aired", series.Seasons[1].AirDate, "2005"},
+		{"IMDB ID", series.ExternalIDs.ImdbID, "tt0386676"},
+		{"episodes", len(season.Episodes), 2},
+		{"season premiered", season.AirDate, "2005"},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.expected {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.expected)
+		}
+	}
+
+	if _, err := fetchTMDBSeason(2316, 9, "key"); err == nil {
+		t.Error("missing season gave no error")
+	}
+}
+
+func TestRenderSeries(t *testing.T) {
+	tvParsed, err := loadTemplate("", "tv.adoc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seasonParsed, err := loadTemplate("", "season.adoc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	series := TMDBSeries{
+		Name:         "The Office",
+		OriginalName: "The Office",
+		FirstAirDate: "2005",
+		Seasons: []TMDBSeasonRef{
+			{Name: "Season 1", EpisodeCount: 6, AirDate: "2005", Identifier: "the-office-season-1-2005"},
+		},
+	}
+
+	season := TMDBSeason{
+		Name:     "Season 1",
+		AirDate:  "2005",
+		Episodes: []TMDBEpisode{{EpisodeNumber: 1, Name: "Pilot", AirDate: "2005-03-24", Runtime: 23}},
+	}
+
+	tests := []struct {
+		name     string
+		tmpl     string
+		data     any
+		expected []string
+		missing  []string
+	}{
+		{
+			name:     "series with season articles",
+			tmpl:     "tv",
+			data:     tvArticle{TMDBSeries: series, SeasonArticles: true},
+			expected: []string{"The Office is a TV series that first aired in 2005.", "- [[the-office-season-1-2005|Season 1]] (2005), 6 episodes"},
+		},
+		{
+			name:     "series without season articles",
+			tmpl:     "tv",
+			data:     tvArticle{TMDBSeries: series},
+			expected: []string{"- Season 1 (2005), 6 episodes"},
+			missing:  []string{"[[the-office-season-1-2005"},
+		},
+		{
+			name:     "season",
+			tmpl:     "season",
+			data:     seasonArticle{TMDBSeason: season, Series: "The Office", SeriesIdentifier: "the-office-2005"},
+			expected: []string{"Season 1 is a season of [[the-office-2005|The Office]] that premiered in 2005, with 1 episodes.", "|1 |Pilot |2005-03-24 |23 min"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := tvParsed
+			if tt.tmpl == "season" {
+				tmpl = seasonParsed
+			}
+
+			content, err := execute(tmpl, tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, expected := range tt.expected {
+				if !strings.Contains(content, expected) {
+					t.Errorf("article is missing %q:\n%s", expected, content)
+				}
+			}
+			for _, missing := range tt.missing {
+				if strings.Contains(content, missing) {
+					t.Errorf("article has %q:\n%s", missing, content)
+				}
+			}
+		})
+	}
+}
+
+func TestPublishEmptySeries(t *testing.T) {
+	serveTMDB(t, map[string]string{
+		"/tv/1?aggregate_credits,external_ids": `{"success": true}`,
+	})
+
+	if err := publishSeries(context.Background(), TvParams{}, 1); err == nil || !strings.Contains(err.Error(), "no ID or name") {
+		t.Errorf("empty series: %v", err)
+	}
+}
+
+func TestSeriesIdentifiers(t *testing.T) {
+	chdirTemp(t)
+
+	series, err := loadIdentifierIndex("tv.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer series.Close()
+
+	seasons, err := loadIdentifierIndex("seasons.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer seasons.Close()
+
+	uk := TMDBSeries{ID: 2996, Name: "The Office", FirstAirDate: "2001"}
+	us := TMDBSeries{ID: 2316, Name: "The Office", FirstAirDate: "2005"}
+
+	tests := []struct {
+		index    *identifierIndex
+		id       int
+		title    string
+		year     string
+		expected string
+	}{
+		{series, uk.ID, uk.Name, uk.FirstAirDate, "the-office"},
+		{seasons, 7001, seasonTitle(uk, "Series 1"), uk.FirstAirDate, "the-office-series-1"},
+		{series, us.ID, us.Name, us.FirstAirDate, "the-office-2005"},
+		{seasons, 3626, seasonTitle(us, "Season 1"), us.FirstAirDate, "the-office-season-1"},
+		// the British one moved away when the American one showed up
+		{series, uk.ID, uk.Name, uk.FirstAirDate, "the-office-2001"},
+	}
+
+	for _, tt := range tests {
+		assigned, err := tt.index.assign(tt.id, tt.title, tt.year)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if assigned.Identifier != tt.expected {
+			t.Errorf("%s (%d) = %q, want %q", tt.title, tt.id, assigned.Identifier, tt.expected)
+		}
+	}
+}
@@ -3,9 +3,12 @@ package movies
 type TMDBMovie struct {
 	Adult               bool   `json:"adult"`
 	BackdropPath        string `json:"backdrop_path"`
-	BelongsToCollection any    `json:"belongs_to_collection"`
-	Budget              int    `json:"budget"`
-	Genres              []struct {
+	BelongsToCollection *struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"belongs_to_collection"`
+	Budget int `json:"budget"`
+	Genres []struct {
 		ID   int    `json:"id"`
 		Name string `json:"name"`
 	} `json:"genres"`
@@ -55,17 +58,38 @@ type TMDBMovie struct {
 		CreditID           string  `json:"credit_id"`
 		Order              int     `json:"order"`
 	} `json:"cast"`
+	Crew         []TMDBCrewMember `json:"crew"`
+	Translations struct {
+		Translations []TMDBTranslation `json:"translations"`
+	} `json:"translations"`
 }
 
-type TMDBPersonApiResult struct {
-	Page         int `json:"page"`
-	TotalResults int `json:"total_results"`
-	TotalPages   int `json:"total_pages"`
-	Results      []TMDBPerson
+// TMDBTranslation holds the fields TMDB translates, empty when they are
+// the same as the original
+type TMDBTranslation struct {
+	Iso31661 string `json:"iso_3166_1"`
+	Iso6391  string `json:"iso_639_1"`
+	Data     struct {
+		Title    string `json:"title"`
+		Overview string `json:"overview"`
+		Tagline  string `json:"tagline"`
+		Homepage string `json:"homepage"`
+	} `json:"data"`
+}
+
+type TMDBCrewMember struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Department string `json:"department"`
+	Job        string `json:"job"`
 }
 
 type KnownFor struct {
-	Title string `json:"title,omitempty"`
+	ID        int    `json:"id,omitempty"`
+	MediaType string `json:"media_type,omitempty"`
+	Title     string `json:"title,omitempty"`
+	// where it is published, set before rendering
+	Identifier string `json:"-"`
 }
 
 type TMDBPerson struct {
@@ -85,6 +109,34 @@ type TMDBPerson struct {
 	Popularity         float64    `json:"popularity"`
 	ProfilePath        string     `json:"profile_path"`
 	KnownFor           []KnownFor `json:"known_for"`
+	CombinedCredits    struct {
+		Cast []TMDBPersonCredit `json:"cast"`
+		Crew []TMDBPersonCredit `json:"crew"`
+	} `json:"combined_credits"`
+	ExternalIDs struct {
+		ImdbID     string `json:"imdb_id"`
+		WikidataID string `json:"wikidata_id"`
+	} `json:"external_ids"`
+	// built from the combined credits before rendering
+	Filmography []FilmographyDepartment `json:"-"`
+}
+
+// TMDBPersonCredit is a movie or a TV show in a person's combined credits,
+// TV shows have a name and a first air date instead of a title and a
+// release date
+type TMDBPersonCredit struct {
+	ID           int     `json:"id"`
+	MediaType    string  `json:"media_type"`
+	Title        string  `json:"title"`
+	Name         string  `json:"name"`
+	ReleaseDate  string  `json:"release_date"`
+	FirstAirDate string  `json:"first_air_date"`
+	Character    string  `json:"character"`
+	Department   string  `json:"department"`
+	Job          string  `json:"job"`
+	Popularity   float64 `json:"popularity"`
+	Adult        bool    `json:"adult"`
+	Video        bool    `json:"video"`
 }
 
 type OMDBMovie struct {
@@ -116,4 +168,122 @@ type OMDBMovie struct {
 	Production string `json:"Production"`
 	Website    string `json:"Website"`
 	Response   string `json:"Response"`
+	// set instead of the movie fields when Response is "False"
+	Error string `json:"Error"`
+}
+
+type TMDBSeries struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	OriginalName string `json:"original_name"`
+	Overview     string `json:"overview"`
+	Tagline      string `json:"tagline"`
+	Status       string `json:"status"`
+	Type         string `json:"type"`
+	FirstAirDate string `json:"first_air_date"`
+	LastAirDate  string `json:"last_air_date"`
+	InProduction bool   `json:"in_production"`
+	Homepage     string `json:"homepage"`
+	PosterPath   string `json:"poster_path"`
+	CreatedBy    []struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"created_by"`
+	Networks []struct {
+		ID            int    `json:"id"`
+		Name          string `json:"name"`
+		OriginCountry string `json:"origin_country"`
+	} `json:"networks"`
+	Genres []struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"genres"`
+	OriginCountry    []string        `json:"origin_country"`
+	EpisodeRunTime   []int           `json:"episode_run_time"`
+	NumberOfSeasons  int             `json:"number_of_seasons"`
+	NumberOfEpisodes int             `json:"number_of_episodes"`
+	Seasons          []TMDBSeasonRef `json:"seasons"`
+	Popularity       float64         `json:"popularity"`
+	VoteAverage      float64         `json:"vote_average"`
+	VoteCount        int             `json:"vote_count"`
+	AggregateCredits struct {
+		Cast []struct {
+			ID    int    `json:"id"`
+			Name  string `json:"name"`
+			Roles []struct {
+				Character    string `json:"character"`
+				EpisodeCount int    `json:"episode_count"`
+			} `json:"roles"`
+			TotalEpisodeCount int `json:"total_episode_count"`
+		} `json:"cast"`
+	} `json:"aggregate_credits"`
+	ExternalIDs struct {
+		ImdbID string `json:"imdb_id"`
+	} `json:"external_ids"`
+}
+
+type TMDBSeasonRef struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	SeasonNumber int    `json:"season_number"`
+	EpisodeCount int    `json:"episode_count"`
+	AirDate      string `json:"air_date"`
+	// where the season article is published, set before rendering
+	Identifier string `json:"-"`
+}
+
+type TMDBSeason struct {
+	ID           int           `json:"id"`
+	Name         string        `json:"name"`
+	SeasonNumber int           `json:"season_number"`
+	AirDate      string        `json:"air_date"`
+	Overview     string        `json:"overview"`
+	PosterPath   string        `json:"poster_path"`
+	Episodes     []TMDBEpisode `json:"episodes"`
+}
+
+type TMDBEpisode struct {
+	EpisodeNumber int     `json:"episode_number"`
+	Name          string  `json:"name"`
+	AirDate       string  `json:"air_date"`
+	Overview      string  `json:"overview"`
+	Runtime       int     `json:"runtime"`
+	VoteAverage   float64 `json:"vote_average"`
+}
+
+// TMDBMovieRef is a movie as it appears in collections and discover results
+type TMDBMovieRef struct {
+	ID          int     `json:"id"`
+	Title       string  `json:"title"`
+	ReleaseDate string  `json:"release_date"`
+	Popularity  float64 `json:"popularity"`
+	Adult       bool    `json:"adult"`
+	Video       bool    `json:"video"`
+}
+
+type TMDBCollection struct {
+	ID         int            `json:"id"`
+	Name       string         `json:"name"`
+	Overview   string         `json:"overview"`
+	PosterPath string         `json:"poster_path"`
+	Parts      []TMDBMovieRef `json:"parts"`
+}
+
+type TMDBCompany struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	Headquarters  string `json:"headquarters"`
+	Homepage      string `json:"homepage"`
+	LogoPath      string `json:"logo_path"`
+	OriginCountry string `json:"origin_country"`
+	ParentCompany *struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"parent_company"`
+}
+
+type TMDBGenre struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
 }
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"slices"
 	"strings"
 	"time"
 
@@ -14,7 +13,6 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"github.com/nbd-wtf/go-nostr"
 	"github.com/nbd-wtf/go-nostr/nip54"
-	"golang.org/x/net/html"
 )
 
 // BehindTheNameParams handles Nostr configuration and operations for the behindthename package
@@ -83,7 +81,12 @@ func doPage(ctx context.Context, params *BehindTheNameParams, num int) (bool, er
 	}
 	defer resp.Body.Close()
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	body, err := common.DecodeHTML(resp.Body, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return false, fmt.Errorf("decode page HTML: %w", err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(body)
 	if err != nil {
 		return false, fmt.Errorf("parse page HTML: %w", err)
 	}
@@ -106,6 +109,17 @@ func doPage(ctx context.Context, params *BehindTheNameParams, num int) (bool, er
 	return true, nil
 }
 
+// nameLinks writes links to other names as wikilinks and keeps only the
+// text of the rest
+var nameLinks = common.HTMLConverter{
+	Link: func(href string, text string) string {
+		if strings.HasPrefix(href, "/name/") {
+			return "[[" + text + "]]"
+		}
+		return text
+	},
+}
+
 func doName(ctx context.Context, params *BehindTheNameParams, url string, name string) error {
 	var resp *http.Response
 	var err error
@@ -124,7 +138,12 @@ func doName(ctx context.Context, params *BehindTheNameParams, url string, name s
 	}
 	defer resp.Body.Close()
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	body, err := common.DecodeHTML(resp.Body, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return fmt.Errorf("decode name page HTML: %w", err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(body)
 	if err != nil {
 		return fmt.Errorf("parse name page HTML: %w", err)
 	}
@@ -134,44 +153,7 @@ func doName(ctx context.Context, params *BehindTheNameParams, url string, name s
 		return fmt.Errorf("no name definition found for %s", name)
 	}
 
-	def := ""
-	for c := nameNodes[0].FirstChild; c != nil; c = c.NextSibling {
-		switch c.Type {
-		case html.TextNode:
-			def += c.Data
-		case html.ElementNode:
-			switch c.Data {
-			case "em", "span", "small":
-				if c.FirstChild != nil {
-					def += c.FirstChild.Data
-				}
-			case "a":
-				if c.FirstChild == nil {
-					params.logger.Printf("Skipping empty link tag in definition for %s", name)
-					continue
-				}
-				if slices.ContainsFunc(c.Attr, func(a html.Attribute) bool { return a.Key == "href" && strings.HasPrefix(a.Val, "/name/") }) {
-					name := strings.TrimSpace(c.FirstChild.Data)
-					if name == "" {
-						params.logger.Printf("Skipping link with empty text in definition for %s", name)
-						continue
-					}
-					def += "[[" + name + "]]"
-				} else {
-					def += c.FirstChild.Data
-				}
-			case "i":
-				if c.FirstChild != nil {
-					def += "_" + c.FirstChild.Data + "_"
-				}
-			case "b":
-				if c.FirstChild != nil {
-					def += "**" + c.FirstChild.Data + "**"
-				}
-			}
-		}
-	}
-	def = strings.TrimSpace(def)
+	def := nameLinks.Convert(nameNodes[0])
 	def += "\n\nhttps://www.behindthename.com/name/" + strings.Split(url, "/name/")[1]
 
 	d := nip54.NormalizeIdentifier(name)
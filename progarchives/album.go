@@ -3,62 +3,452 @@ package progarchives
 import (
 	"fmt"
 	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
-	"golang.org/x/net/html/charset"
+	"github.com/nbd-wtf/go-nostr/nip54"
+	"golang.org/x/net/html"
 )
 
-func album(id uint64) (string, string, error) {
+// Album is what album.asp says about an album
+type Album struct {
+	Title  string
+	Artist string
+	// 0 when the page doesn't link to the artist
+	ArtistID uint64
+	// where the album and its artist are published, set before writing
+	// the article
+	Identifier       string
+	ArtistIdentifier string
+	// "Studio Album", "Live", "Boxset/Compilation"...
+	Type     string
+	Year     string
+	Cover    string
+	Tracks   []TrackGroup
+	Lineup   []LineupGroup
+	Releases []string
+	// nil when nobody rated it yet
+	Rating *RatingSummary
+	// the sum of the track durations, or what the page says when some are
+	// missing
+	TotalTime string
+}
+
+// TrackGroup is a side or a disc, the whole album when it has no label
+type TrackGroup struct {
+	Label  string
+	Tracks []Track
+}
+
+type Track struct {
+	Number   int
+	Title    string
+	Duration string
+	// movements of a suite, listed under it
+	Parts []string
+}
+
+// LineupGroup is the band itself or its guests, when labeled so
+type LineupGroup struct {
+	Label     string
+	Musicians []Musician
+}
+
+type Musician struct {
+	Name        string
+	Instruments string
+}
+
+type AlbumOptions struct {
+	// publish an article quoting the album's reviews along with it
+	Reviews bool
+	// how many reviews to quote, 0 for all of them
+	MaxReviews int
+}
+
+var (
+	releasedRegex  = regexp.MustCompile(`^(.+?),\s*released in (\d{4})`)
+	trackRegex     = regexp.MustCompile(`^(\d+)\s*[.)-]\s*(.+?)\s*(?:\((\d{1,2}:\d{2}(?::\d{2})?)\))?\s*:?$`)
+	totalTimeRegex = regexp.MustCompile(`(?i)^total\s+time:?\s*(\d{1,2}:\d{2}(?::\d{2})?)`)
+	partRegex      = regexp.MustCompile(`^(?:-|[a-z]\)|[ivx]+\.)\s*(.+)$`)
+)
+
+func album(id uint64, options AlbumOptions, indexes Indexes) ([]Article, error) {
 	params := url.Values{"id": {strconv.FormatUint(id, 10)}}
 	requestUrl := "https://www.progarchives.com/album.asp?" + params.Encode()
 
 	logger.Printf("Fetching album from %s\n", requestUrl)
 
-	r, err := makeRequest(requestUrl)
+	doc, err := fetchDocument(requestUrl)
 	if err != nil {
-		return "", "", fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
 
-	ct := r.Header.Get("Content-Type")
-	bodyReader, err := charset.NewReader(r.Body, ct)
+	a, err := parseAlbum(doc)
 	if err != nil {
-		return "", "", fmt.Errorf("charset reader failed: %w", err)
+		return nil, err
 	}
 
-	doc, err := goquery.NewDocumentFromReader(bodyReader)
+	logger.Printf("Processing album: %s\n", a.Title)
+
+	title := albumTitle(a.Title, a.Artist)
+	a.Identifier, err = indexes.Albums.assign(id, title, a.Year)
 	if err != nil {
-		return "", "", fmt.Errorf("goquery parse failed: %w", err)
+		return nil, err
+	}
+	if a.ArtistID != 0 {
+		a.ArtistIdentifier = indexes.Artists.identifier(a.ArtistID, a.Artist)
+	}
+
+	articles := []Article{{Title: title, Identifier: a.Identifier, AsciiDoc: a.asciiDoc()}}
+
+	if options.Reviews {
+		if reviews := parseReviews(doc, options.MaxReviews); len(reviews) > 0 {
+			articles = append(articles, reviewsArticle(a, reviews))
+		}
 	}
-	r.Body.Close()
 
+	return articles, nil
+}
+
+func parseAlbum(doc *goquery.Document) (Album, error) {
 	title, err := getTitle(doc)
 	if err != nil {
-		return "", "", fmt.Errorf("get title failed: %w", err)
+		return Album{}, fmt.Errorf("get title failed: %w", err)
+	}
+
+	a := Album{
+		Title:  strings.TrimSpace(title),
+		Artist: strings.TrimSpace(doc.Find(`h2`).Eq(0).Text()),
+	}
+
+	if link := doc.Find(`h2 a[href*="artist.asp?id="]`); link.Length() > 0 {
+		a.ArtistID = linkedID(link.First())
+	} else {
+		doc.Find(`a[href*="artist.asp?id="]`).EachWithBreak(func(i int, link *goquery.Selection) bool {
+			if strings.EqualFold(strings.TrimSpace(link.Text()), a.Artist) {
+				a.ArtistID = linkedID(link)
+				return false
+			}
+			return true
+		})
+	}
+
+	if image, ok := doc.Find(`#imgCover`).Attr("src"); ok {
+		a.Cover = "https://www.progarchives.com/" + strings.TrimPrefix(image, "/")
+	}
+
+	doc.Find(`strong`).EachWithBreak(func(i int, s *goquery.Selection) bool {
+		if match := releasedRegex.FindStringSubmatch(strings.TrimSpace(s.Text())); match != nil {
+			a.Type = match[1]
+			a.Year = match[2]
+			return false
+		}
+		return true
+	})
+
+	a.Rating = parseRatingSummary(doc)
+
+	// each section is a heading followed by lines separated by <br>
+	doc.Find(`h4`).Each(func(i int, s *goquery.Selection) {
+		heading := strings.ToLower(s.Text())
+		lines := sectionLines(s.NextUntil(`h1, h2, h3, h4`))
+
+		switch {
+		case strings.Contains(heading, "tracks listing"):
+			a.Tracks, a.TotalTime = parseTracks(lines)
+		case strings.Contains(heading, "line-up"):
+			a.Lineup = parseLineup(lines)
+		case strings.Contains(heading, "releases information"):
+			a.Releases = lines
+		}
+	})
+
+	return a, nil
+}
+
+// blockElements start lines of their own
+var blockElements = map[string]bool{
+	"p": true, "div": true, "li": true, "tr": true, "td": true, "table": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+// sectionLines is the text of a section split where the page breaks lines
+func sectionLines(section *goquery.Selection) []string {
+	var lines []string
+	line := strings.Builder{}
+
+	flush := func() {
+		if text := strings.Join(strings.Fields(line.String()), " "); text != "" {
+			lines = append(lines, text)
+		}
+		line.Reset()
+	}
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		switch node.Type {
+		case html.TextNode:
+			line.WriteString(node.Data)
+		case html.ElementNode:
+			switch node.Data {
+			case "br":
+				flush()
+				return
+			case "script", "style", "form", "img":
+				return
+			}
+
+			block := blockElements[node.Data]
+			if block {
+				flush()
+			}
+			for child := node.FirstChild; child != nil; child = child.NextSibling {
+				walk(child)
+			}
+			if block {
+				flush()
+			}
+		}
+	}
+
+	for _, node := range section.Nodes {
+		walk(node)
+	}
+	flush()
+
+	return lines
+}
+
+// parseTracks reads lines like "1. Watcher of the Skies (7:21)", with
+// "Side 1" or "CD 2" labels between them and "Total Time 45:41" at the end
+func parseTracks(lines []string) ([]TrackGroup, string) {
+	var groups []TrackGroup
+	totalTime := ""
+
+	group := func() *TrackGroup {
+		if len(groups) == 0 {
+			groups = append(groups, TrackGroup{})
+		}
+		return &groups[len(groups)-1]
+	}
+
+	for _, line := range lines {
+		if match := totalTimeRegex.FindStringSubmatch(line); match != nil {
+			totalTime = match[1]
+			continue
+		}
+
+		if match := trackRegex.FindStringSubmatch(line); match != nil {
+			number, _ := strconv.Atoi(match[1])
+			g := group()
+			g.Tracks = append(g.Tracks, Track{
+				Number:   number,
+				Title:    match[2],
+				Duration: match[3],
+			})
+			continue
+		}
+
+		g := group()
+		if match := partRegex.FindStringSubmatch(line); match != nil && len(g.Tracks) > 0 {
+			last := &g.Tracks[len(g.Tracks)-1]
+			last.Parts = append(last.Parts, match[1])
+			continue
+		}
+
+		label := strings.TrimSuffix(line, ":")
+		if len(g.Tracks) == 0 {
+			g.Label = label
+		} else {
+			groups = append(groups, TrackGroup{Label: label})
+		}
+	}
+
+	// discs can have totals of their own, the sum is the album's
+	if sum := sumDurations(groups); sum != "" {
+		totalTime = sum
+	}
+
+	return groups, totalTime
+}
+
+// sumDurations adds the durations of all tracks, or returns "" when any of
+// them is missing
+func sumDurations(groups []TrackGroup) string {
+	var total time.Duration
+	for _, g := range groups {
+		for _, track := range g.Tracks {
+			duration, ok := parseDuration(track.Duration)
+			if !ok {
+				return ""
+			}
+			total += duration
+		}
+	}
+
+	if total == 0 {
+		return ""
+	}
+
+	return formatDuration(total)
+}
+
+// parseDuration reads "7:21" and "1:02:33"
+func parseDuration(s string) (time.Duration, bool) {
+	var total time.Duration
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, false
+	}
+
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, false
+		}
+		total = total*60 + time.Duration(n)
+	}
+
+	return total * time.Second, true
+}
+
+func formatDuration(d time.Duration) string {
+	seconds := int(d / time.Second)
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// parseLineup reads lines like "- Peter Gabriel / lead vocals, flute", with
+// labels like "With:" or "Guest musicians:" between them
+func parseLineup(lines []string) []LineupGroup {
+	var groups []LineupGroup
+
+	for _, line := range lines {
+		line = strings.TrimSpace(strings.TrimLeft(line, "-–• "))
+		if line == "" {
+			continue
+		}
+
+		name, instruments, found := strings.Cut(line, " / ")
+		if !found {
+			name, instruments, found = strings.Cut(line, ": ")
+		}
+		if !found && strings.HasSuffix(line, ":") {
+			groups = append(groups, LineupGroup{Label: strings.TrimSuffix(line, ":")})
+			continue
+		}
+
+		if len(groups) == 0 {
+			groups = append(groups, LineupGroup{})
+		}
+		g := &groups[len(groups)-1]
+		g.Musicians = append(g.Musicians, Musician{
+			Name:        strings.TrimSpace(name),
+			Instruments: strings.TrimSpace(instruments),
+		})
 	}
 
-	logger.Printf("Processing album: %s\n", title)
+	return groups
+}
 
-	title = title + " (album)"
+func (a Album) asciiDoc() string {
+	content := strings.Builder{}
 
-	artist := doc.Find(`h2`).Eq(0).Text()
+	content.WriteString(firstNonEmpty(a.Type, "Album"))
+	if a.Artist != "" {
+		content.WriteString(" by " + a.artistLink())
+	}
+	if a.Year != "" {
+		content.WriteString(fmt.Sprintf(", released in %s", a.Year))
+	}
+	content.WriteString(".\n")
 
-	image, _ := doc.Find(`#imgCover`).Attr("src")
-	imageUrl := "https://www.progarchives.com/" + image
+	if a.Cover != "" {
+		content.WriteString(fmt.Sprintf("\nimage::%s[]\n", a.Cover))
+	}
 
-	textContainer := doc.Find(`td`).Eq(1)
+	if a.Rating != nil {
+		content.WriteString(a.Rating.asciiDoc())
+	}
+
+	if len(a.Tracks) > 0 {
+		content.WriteString("\n== Tracklist\n")
+
+		for _, g := range a.Tracks {
+			if g.Label != "" {
+				content.WriteString(fmt.Sprintf("\n=== %s\n", g.Label))
+			}
+			content.WriteByte('\n')
 
-	text := strings.Builder{}
-	for _, node := range textContainer.Children().Nodes {
-		nodeToString(&text, node)
+			for _, track := range g.Tracks {
+				content.WriteString(fmt.Sprintf("%d. %s", track.Number, track.Title))
+				if track.Duration != "" {
+					content.WriteString(fmt.Sprintf(" (%s)", track.Duration))
+				}
+				content.WriteByte('\n')
+
+				for _, part := range track.Parts {
+					content.WriteString(fmt.Sprintf("* %s\n", part))
+				}
+			}
+		}
+
+		if a.TotalTime != "" {
+			content.WriteString(fmt.Sprintf("\nTotal time:: %s\n", a.TotalTime))
+		}
 	}
 
-	return title, fmt.Sprintf(`album from [[%s]]
+	if len(a.Lineup) > 0 {
+		content.WriteString("\n== Line-up\n")
+
+		for _, g := range a.Lineup {
+			if g.Label != "" {
+				content.WriteString(fmt.Sprintf("\n=== %s\n", g.Label))
+			}
+			content.WriteByte('\n')
 
-image::%s[]
+			for _, musician := range g.Musicians {
+				content.WriteString(fmt.Sprintf("- [[%s]]", musician.Name))
+				if musician.Instruments != "" {
+					content.WriteString(": " + musician.Instruments)
+				}
+				content.WriteByte('\n')
+			}
+		}
+	}
+
+	if len(a.Releases) > 0 {
+		content.WriteString("\n== Releases\n\n")
+
+		for _, release := range a.Releases {
+			content.WriteString(fmt.Sprintf("- %s\n", release))
+		}
+	}
+
+	return content.String()
+}
+
+func (a Album) artistLink() string {
+	return wikilink(firstNonEmpty(a.ArtistIdentifier, nip54.NormalizeIdentifier(a.Artist)), a.Artist)
+}
+
+func (a Album) link() string {
+	return wikilink(firstNonEmpty(a.Identifier, nip54.NormalizeIdentifier(albumTitle(a.Title, a.Artist))), a.Title)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
 
-%s`,
-		artist, imageUrl, text.String(),
-	), nil
+	return ""
 }
@@ -0,0 +1,139 @@
+package progarchives
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func loadFixture(t *testing.T, name string) *goquery.Document {
+	t.Helper()
+
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	doc, err := goquery.NewDocumentFromReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return doc
+}
+
+func TestParseAlbum(t *testing.T) {
+	a, err := parseAlbum(loadFixture(t, "album.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.Title != "Foxtrot" || a.Artist != "Genesis" || a.Type != "Studio Album" || a.Year != "1972" {
+		t.Errorf("album = %q by %q, %q from %q", a.Title, a.Artist, a.Type, a.Year)
+	}
+
+	if len(a.Tracks) != 2 || a.Tracks[0].Label != "Side 1" || a.Tracks[1].Label != "Side 2" {
+		t.Fatalf("track groups = %+v", a.Tracks)
+	}
+	suite := a.Tracks[1].Tracks[1]
+	if suite.Number != 6 || suite.Title != "Supper's Ready" || suite.Duration != "22:58" || len(suite.Parts) != 2 {
+		t.Errorf("suite = %+v", suite)
+	}
+	if a.TotalTime != "51:05" {
+		t.Errorf("total time = %q", a.TotalTime)
+	}
+
+	if len(a.Lineup) != 2 || a.Lineup[1].Label != "Guest musicians" {
+		t.Fatalf("lineup = %+v", a.Lineup)
+	}
+	if hackett := a.Lineup[0].Musicians[1]; hackett.Name != "Steve Hackett" || hackett.Instruments != "electric & 12-string guitars" {
+		t.Errorf("musician = %+v", hackett)
+	}
+	if banks := a.Lineup[1].Musicians[0]; banks.Name != "Tony Banks" || banks.Instruments != "organ, Mellotron" {
+		t.Errorf("guest = %+v", banks)
+	}
+
+	if len(a.Releases) != 2 {
+		t.Errorf("releases = %q", a.Releases)
+	}
+
+	content := a.asciiDoc()
+	for _, expected := range []string{
+		"Studio Album by [[Genesis]], released in 1972.",
+		"=== Side 2\n\n5. Horizons (1:39)\n6. Supper's Ready (22:58)\n* i. Lover's Leap\n",
+		"Total time:: 51:05",
+		"- [[Peter Gabriel]]: lead vocals, flute, tambourine",
+		"- LP Charisma - CAS 1058 (1972, UK)",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("article is missing %q:\n%s", expected, content)
+		}
+	}
+}
+
+func TestParseTracksWithoutDurations(t *testing.T) {
+	groups, total := parseTracks([]string{"1. Intro", "2. Outro (3:00)", "Total time: 4:10"})
+	if len(groups) != 1 || len(groups[0].Tracks) != 2 || groups[0].Label != "" {
+		t.Fatalf("groups = %+v", groups)
+	}
+	// one duration is missing so the page's total stays
+	if total != "4:10" {
+		t.Errorf("total = %q", total)
+	}
+}
+
+func TestParseRatingsAndReviews(t *testing.T) {
+	doc := loadFixture(t, "album.html")
+
+	a, err := parseAlbum(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Rating == nil || a.Rating.Average != "4.65" || a.Rating.Count != 3123 || len(a.Rating.Levels) != 5 {
+		t.Fatalf("rating = %+v", a.Rating)
+	}
+	if level := a.Rating.Levels[1]; level.Label != "Excellent addition to any prog rock music collection" || level.Percent != 27 {
+		t.Errorf("level = %+v", level)
+	}
+	if !strings.Contains(a.asciiDoc(), "Average rating:: 4.65 out of 5, from 3123 ratings") {
+		t.Errorf("article:\n%s", a.asciiDoc())
+	}
+
+	reviews := parseReviews(doc, 0)
+	if len(reviews) != 3 {
+		t.Fatalf("reviews = %+v", reviews)
+	}
+	if r := reviews[0]; r.Author != "Sean Trane" || r.Stars != 5 || r.Date != "March 1, 2004" ||
+		r.Text != "Genesis at their peak. Supper's Ready alone would make this essential." {
+		t.Errorf("review = %+v", r)
+	}
+	// stars are clamped and lines mentioning a short author name are kept
+	if r := reviews[2]; r.Author != "Al" || r.Stars != 5 || r.Date != "March 3, 2004" ||
+		r.Text != "Total bliss. Al Stewart once called it his favourite album." {
+		t.Errorf("review = %+v", r)
+	}
+
+	if reviews := parseReviews(doc, 1); len(reviews) != 1 {
+		t.Errorf("max 1 review gave %d", len(reviews))
+	}
+
+	article := reviewsArticle(a, reviews)
+	if article.Title != "Foxtrot (Genesis album) reviews" || article.Identifier != "foxtrot--genesis-album--reviews" ||
+		!strings.Contains(article.AsciiDoc, "Reviews of [[foxtrot--genesis-album-|Foxtrot]] by [[Genesis]]") ||
+		!strings.Contains(article.AsciiDoc, "★★★☆☆, March 2, 2004") ||
+		!strings.Contains(article.AsciiDoc, "[quote, \"Cesar Inca\", progarchives]") {
+		t.Errorf("reviews article %s:\n%s", article.Title, article.AsciiDoc)
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	if got := excerpt("short", 10); got != "short" {
+		t.Errorf("short = %q", got)
+	}
+	if got := excerpt("one two three, four", 15); got != "one two three…" {
+		t.Errorf("long = %q", got)
+	}
+}
@@ -7,42 +7,29 @@ import (
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
-	"golang.org/x/net/html/charset"
 )
 
-func artist(id uint64) (string, string, error) {
+func artist(id uint64, indexes Indexes) ([]Article, error) {
 	params := url.Values{"id": {strconv.FormatUint(id, 10)}}
 	requestUrl := "https://www.progarchives.com/artist.asp?" + params.Encode()
 
 	logger.Printf("Fetching artist from %s\n", requestUrl)
 
-	r, err := makeRequest(requestUrl)
+	doc, err := fetchDocument(requestUrl)
 	if err != nil {
-		return "", "", fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
 
-	ct := r.Header.Get("Content-Type")
-	bodyReader, err := charset.NewReader(r.Body, ct)
-	if err != nil {
-		return "", "", fmt.Errorf("charset reader failed: %w", err)
-	}
-
-	doc, err := goquery.NewDocumentFromReader(bodyReader)
-	if err != nil {
-		return "", "", fmt.Errorf("goquery parse failed: %w", err)
-	}
-	r.Body.Close()
-
 	title, err := getTitle(doc)
 	if err != nil {
-		return "", "", fmt.Errorf("get title failed: %w", err)
+		return nil, fmt.Errorf("get title failed: %w", err)
 	}
 
 	logger.Printf("Processing artist: %s\n", title)
 
 	cat := doc.Find(`h2`).First().Text()
 	if cat == "" || !strings.Contains(cat, "•") {
-		return "", "", fmt.Errorf("category error: %s", cat)
+		return nil, fmt.Errorf("category error: %s", cat)
 	}
 
 	spl := strings.Split(cat, "•")
@@ -66,22 +53,21 @@ func artist(id uint64) (string, string, error) {
 		bio = moreBio
 	}
 
-	bioText := strings.Builder{}
-	for _, node := range bio.Nodes {
-		nodeToString(&bioText, node)
-	}
+	bioText := htmlConverter(indexes).Convert(bio.Nodes...)
 
-	discography := strings.Builder{}
-	doc.Find("#discography").NextUntil("table").Next().Find("td").Each(func(i int, s *goquery.Selection) {
-		albumTitle := s.Find("a > strong").Text()
-		albumYear := s.Find("a + br + span").Text()
+	identifier, err := indexes.Artists.assign(id, title, country)
+	if err != nil {
+		return nil, err
+	}
 
-		discography.WriteString(fmt.Sprintf(`
-- [[%s (album)]] ([[%s]])
-`, albumTitle, albumYear))
-	})
+	discography := parseDiscography(doc, title)
+	for _, group := range discography {
+		for i, a := range group.Albums {
+			group.Albums[i].Identifier = indexes.Albums.identifier(a.ID, albumTitle(a.Title, title))
+		}
+	}
 
-	return title, fmt.Sprintf(`[[%s]], [[%s]]
+	return []Article{{Title: title, Identifier: identifier, AsciiDoc: fmt.Sprintf(`[[%s]], [[%s]]
 
 image::%s[]
 
@@ -90,6 +76,6 @@ image::%s[]
 == Discography
 
 %s`,
-		category, country, image, bioText.String(), discography.String(),
-	), nil
+		category, country, image, bioText, discographyAsciiDoc(discography),
+	)}}, nil
 }
@@ -0,0 +1,83 @@
+package progarchives
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestParseDiscography(t *testing.T) {
+	groups := parseDiscography(loadFixture(t, "artist.html"), "Genesis")
+
+	if len(groups) != 4 {
+		t.Fatalf("groups = %+v", groups)
+	}
+	for i, kind := range []string{"Studio albums", "Live albums", "Compilations", "Singles and EPs"} {
+		if groups[i].Type != kind {
+			t.Errorf("group %d = %q", i, groups[i].Type)
+		}
+	}
+
+	if foxtrot := groups[0].Albums[1]; foxtrot != (DiscographyAlbum{ID: 3066, Title: "Foxtrot", Year: "1972", Rating: "4.65", Ratings: 3123}) {
+		t.Errorf("album = %+v", foxtrot)
+	}
+	for _, group := range groups {
+		for _, a := range group.Albums {
+			if a.ID == 5000 || a.ID == 4242 {
+				t.Errorf("%s lists %q from outside the discography sections", group.Type, a.Title)
+			}
+		}
+	}
+	if single := groups[3].Albums[0]; single.Rating != "" {
+		t.Errorf("unrated single = %+v", single)
+	}
+
+	groups[0].Albums[1].Identifier = "foxtrot--genesis-album-"
+	groups[3].Albums[0].Identifier = "the-silent-sun--genesis-album-"
+	content := discographyAsciiDoc(groups)
+	for _, expected := range []string{
+		"=== Studio albums\n\n",
+		"- [[foxtrot--genesis-album-|Foxtrot]] (1972), rated 4.65 out of 5 by 3123\n",
+		"=== Live albums\n\n",
+		"- [[the-silent-sun--genesis-album-|The Silent Sun]] (1968)\n",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("discography is missing %q:\n%s", expected, content)
+		}
+	}
+}
+
+func TestBioLinks(t *testing.T) {
+	chdirTemp(t)
+
+	indexes, err := loadIndexes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer indexes.Close()
+
+	indexes.Artists.assign(1, "Nektar", "Germany")
+	indexes.Artists.assign(2, "Nektar", "United Kingdom")
+	indexes.Albums.assign(3066, albumTitle("Foxtrot", "Genesis"))
+
+	doc, err := html.Parse(strings.NewReader(`<p>Friends with <a href="artist.asp?id=2">Nektar</a>, ` +
+		`loved <a href="https://www.progarchives.com/album.asp?id=3066">Foxtrot</a> ` +
+		`and <a href="artist.asp?id=77">Camel</a>, played ` +
+		`<a href="subgenre.asp?style=3">Symphonic Prog</a>.</p>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := htmlConverter(indexes).Convert(doc)
+	for _, expected := range []string{
+		"[[nektar-united-kingdom|Nektar]]",
+		"[[foxtrot--genesis-album-|Foxtrot]]",
+		"[[Camel]]",
+		"[[Symphonic Prog]]",
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("bio is missing %q:\n%s", expected, got)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package progarchives
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ReleaseGroup is one of the discography sections of an artist page, like
+// the live albums
+type ReleaseGroup struct {
+	Type   string
+	Albums []DiscographyAlbum
+}
+
+type DiscographyAlbum struct {
+	ID    uint64
+	Title string
+	Year  string
+	// "" when nobody rated it yet
+	Rating  string
+	Ratings int
+	// where the album is published, set before writing the article
+	Identifier string
+}
+
+// releaseTypes are the discography sections in the order progarchives
+// shows them, matched by a word in their headings like "GENESIS Live Albums
+// (CD, LP, MC, SACD, DVD-A, Digital Media Download)"
+var releaseTypes = []struct {
+	keyword string
+	name    string
+}{
+	{"top albums", "Studio albums"},
+	{"studio", "Studio albums"},
+	{"live", "Live albums"},
+	{"video", "Videos"},
+	{"compilation", "Compilations"},
+	{"single", "Singles and EPs"},
+}
+
+// releaseType is the kind of releases under a heading, leaving the artist
+// name out of it so that bands called something like "Live" don't confuse
+// it, or "" for headings that aren't one of the discography sections
+func releaseType(heading string, artist string) string {
+	heading = strings.ReplaceAll(strings.ToLower(heading), strings.ToLower(artist), "")
+	for _, t := range releaseTypes {
+		if strings.Contains(heading, t.keyword) {
+			return t.name
+		}
+	}
+
+	return ""
+}
+
+// parseDiscography reads the tables between the discography heading and the
+// next heading like it, each one under a heading of its own saying what kind
+// of releases it has. Tables under other headings are left out.
+func parseDiscography(doc *goquery.Document, artist string) []ReleaseGroup {
+	var groups []ReleaseGroup
+
+	discography := doc.Find("#discography").First()
+	if discography.Length() == 0 {
+		return nil
+	}
+
+	kind := ""
+	discography.NextUntil(goquery.NodeName(discography)).Each(func(i int, s *goquery.Selection) {
+		switch {
+		case s.Is("h3, h4"):
+			kind = releaseType(s.Text(), artist)
+		case s.Is("table") && kind != "":
+			albums := parseDiscographyTable(s)
+			if len(albums) == 0 {
+				return
+			}
+
+			if len(groups) > 0 && groups[len(groups)-1].Type == kind {
+				groups[len(groups)-1].Albums = append(groups[len(groups)-1].Albums, albums...)
+			} else {
+				groups = append(groups, ReleaseGroup{Type: kind, Albums: albums})
+			}
+		}
+	})
+
+	return groups
+}
+
+func parseDiscographyTable(table *goquery.Selection) []DiscographyAlbum {
+	var albums []DiscographyAlbum
+
+	table.Find("td").Each(func(i int, cell *goquery.Selection) {
+		title := strings.TrimSpace(cell.Find("a > strong").First().Text())
+		if title == "" {
+			return
+		}
+
+		a := DiscographyAlbum{
+			ID:     linkedID(cell.Find(`a[href*="album.asp?id="]`).First()),
+			Title:  title,
+			Year:   strings.TrimSpace(cell.Find("a + br + span").First().Text()),
+			Rating: strings.TrimSpace(cell.Find(`span[id^="avgRatings"]`).First().Text()),
+		}
+		a.Ratings, _ = strconv.Atoi(strings.TrimSpace(cell.Find(`span[id^="nbRatings"]`).First().Text()))
+
+		// 0.00 is what albums without ratings show
+		if a.Ratings == 0 || strings.Trim(a.Rating, "0.") == "" {
+			a.Rating = ""
+		}
+
+		albums = append(albums, a)
+	})
+
+	return albums
+}
+
+func discographyAsciiDoc(groups []ReleaseGroup) string {
+	content := strings.Builder{}
+
+	for _, group := range groups {
+		content.WriteString(fmt.Sprintf("\n=== %s\n\n", group.Type))
+
+		for _, a := range group.Albums {
+			content.WriteString("- " + wikilink(a.Identifier, a.Title))
+			if a.Year != "" {
+				content.WriteString(" (" + a.Year + ")")
+			}
+			if a.Rating != "" {
+				content.WriteString(fmt.Sprintf(", rated %s out of 5 by %d", a.Rating, a.Ratings))
+			}
+			content.WriteByte('\n')
+		}
+	}
+
+	return strings.TrimPrefix(content.String(), "\n")
+}
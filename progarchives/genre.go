@@ -0,0 +1,113 @@
+package progarchives
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/nbd-wtf/go-nostr/nip54"
+)
+
+// Subgenre is a progarchives category like "Symphonic Prog", the one in the
+// "Category • Country" line of artist pages
+type Subgenre struct {
+	Name       string
+	Definition []string
+	Artists    []GenreArtist
+}
+
+type GenreArtist struct {
+	ID      uint64
+	Name    string
+	Country string
+	// where the artist is published
+	Identifier string
+}
+
+func subgenre(id uint64, indexes Indexes) ([]Article, error) {
+	params := url.Values{"style": {strconv.FormatUint(id, 10)}}
+	requestUrl := "https://www.progarchives.com/subgenre.asp?" + params.Encode()
+
+	logger.Printf("Fetching subgenre from %s\n", requestUrl)
+
+	doc, err := fetchDocument(requestUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := parseSubgenre(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Printf("Processing subgenre: %s\n", g.Name)
+
+	for i, artist := range g.Artists {
+		g.Artists[i].Identifier = indexes.Artists.identifier(artist.ID, artist.Name)
+	}
+
+	return []Article{{Title: g.Name, AsciiDoc: g.asciiDoc()}}, nil
+}
+
+func parseSubgenre(doc *goquery.Document) (Subgenre, error) {
+	title, err := getTitle(doc)
+	if err != nil {
+		return Subgenre{}, fmt.Errorf("get title failed: %w", err)
+	}
+
+	g := Subgenre{
+		Name: strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(title), "definition")),
+	}
+
+	// the definition is everything between the title and the artists table
+	h1 := doc.Find(`h1`).First()
+	g.Definition = sectionLines(h1.NextUntil(`table, h2, h3`))
+
+	seen := make(map[string]bool)
+	doc.Find(`a[href*="artist.asp?id="]`).Each(func(i int, link *goquery.Selection) {
+		name := strings.TrimSpace(link.Text())
+		if name == "" || seen[name] {
+			return
+		}
+		seen[name] = true
+
+		artist := GenreArtist{ID: linkedID(link), Name: name}
+
+		// artist lists are tables with the country in the cell after the name
+		if cell := link.Closest(`td`); cell.Length() > 0 {
+			artist.Country = strings.TrimSpace(cell.Next().Text())
+		}
+
+		g.Artists = append(g.Artists, artist)
+	})
+
+	return g, nil
+}
+
+func (g Subgenre) asciiDoc() string {
+	content := strings.Builder{}
+
+	content.WriteString(fmt.Sprintf("%s is a subgenre of [[progressive rock]].\n", g.Name))
+
+	if len(g.Definition) > 0 {
+		content.WriteString("\n== Definition\n\n")
+		content.WriteString(strings.Join(g.Definition, "\n\n"))
+		content.WriteByte('\n')
+	}
+
+	if len(g.Artists) > 0 {
+		content.WriteString("\n== Artists\n\n")
+
+		for _, artist := range g.Artists {
+			content.WriteString("- " + wikilink(firstNonEmpty(artist.Identifier, nip54.NormalizeIdentifier(artist.Name)), artist.Name))
+			if artist.Country != "" {
+				content.WriteString(fmt.Sprintf(" ([[%s]])", artist.Country))
+			}
+			content.WriteByte('\n')
+		}
+	}
+
+	return content.String()
+}
@@ -0,0 +1,30 @@
+package progarchives
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSubgenre(t *testing.T) {
+	g, err := parseSubgenre(loadFixture(t, "subgenre.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g.Name != "Symphonic Prog" || len(g.Definition) != 2 {
+		t.Errorf("subgenre = %q, definition %q", g.Name, g.Definition)
+	}
+	if len(g.Artists) != 3 || g.Artists[2] != (GenreArtist{ID: 3, Name: "PFM", Country: "Italy"}) {
+		t.Errorf("artists = %+v", g.Artists)
+	}
+
+	content := g.asciiDoc()
+	for _, expected := range []string{
+		"== Definition\n\nSymphonic Prog is a form",
+		"- [[Yes]] ([[United Kingdom]])",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("article is missing %q:\n%s", expected, content)
+		}
+	}
+}
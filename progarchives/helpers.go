@@ -4,73 +4,45 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net/http"
-	"slices"
+	"strconv"
 	"strings"
-	"unicode/utf8"
+
+	"fiatjaf/wiki-importer/common"
 
 	"github.com/PuerkitoBio/goquery"
-	"golang.org/x/net/html"
 )
 
-func nodeToString(str *strings.Builder, node *html.Node) {
-	switch node.Type {
-	case html.TextNode:
-		str.WriteString(removeNonUtf8(node.Data))
-	case html.ElementNode:
-		switch node.Data {
-		case "span":
-			for nextNode := node.FirstChild; nextNode != nil; nextNode = nextNode.NextSibling {
-				nodeToString(str, nextNode)
-			}
-		case "br":
-			str.WriteByte('\n')
-		case "p":
-			str.WriteByte('\n')
-			for nextNode := node.FirstChild; nextNode != nil; nextNode = nextNode.NextSibling {
-				nodeToString(str, nextNode)
-			}
-			str.WriteByte('\n')
-		case "b":
-			str.WriteString("**")
-			for nextNode := node.FirstChild; nextNode != nil; nextNode = nextNode.NextSibling {
-				nodeToString(str, nextNode)
-			}
-			str.WriteString("**")
-		case "i":
-			str.WriteByte('_')
-			for nextNode := node.FirstChild; nextNode != nil; nextNode = nextNode.NextSibling {
-				nodeToString(str, nextNode)
-			}
-			str.WriteByte('_')
-		case "a":
-			if node.FirstChild == nil {
-				return
+// htmlConverter writes links to other progarchives pages as wikilinks,
+// pointing at the identifiers artists and albums got when the indexes have
+// them
+func htmlConverter(indexes Indexes) common.HTMLConverter {
+	return common.HTMLConverter{
+		Link: func(href string, text string) string {
+			href = strings.TrimPrefix(strings.TrimPrefix(href, "https://www.progarchives.com/"), "http://www.progarchives.com/")
+			if !strings.Contains(href, ".asp") || strings.Contains(href, "://") {
+				return ""
 			}
 
-			targetIdx := slices.IndexFunc(node.Attr, func(a html.Attribute) bool { return a.Key == "href" })
-			if targetIdx == -1 {
-				str.WriteString(removeNonUtf8(node.FirstChild.Data))
-				return
+			if match := linkIDRegex.FindStringSubmatch(href); match != nil {
+				var idx *identifierIndex
+				switch strings.ToLower(match[1]) {
+				case "artist":
+					idx = indexes.Artists
+				case "album":
+					idx = indexes.Albums
+				}
+
+				id, _ := strconv.ParseUint(match[2], 10, 64)
+				if idx != nil {
+					if identifier, ok := idx.Lookup(id); ok {
+						return wikilink(identifier, text)
+					}
+				}
 			}
 
-			target := node.Attr[targetIdx].Val
-			if strings.HasPrefix(target, "http") || !strings.Contains(target, ".asp") {
-				str.WriteString(fmt.Sprintf("%s[%s]", target, node.FirstChild.Data))
-			} else {
-				str.WriteString(fmt.Sprintf("[[%s]]", node.FirstChild.Data))
-			}
-		}
-	}
-}
-
-func removeNonUtf8(str string) string {
-	var sb strings.Builder
-	for _, r := range str {
-		if utf8.ValidRune(r) {
-			sb.WriteRune(r)
-		}
+			return "[[" + text + "]]"
+		},
 	}
-	return sb.String()
 }
 
 func getHttpClient() *http.Client {
@@ -96,6 +68,27 @@ func getTitle(doc *goquery.Document) (string, error) {
 	return title, nil
 }
 
+// fetchDocument gets and parses a page in whatever charset it's in
+func fetchDocument(url string) (*goquery.Document, error) {
+	r, err := makeRequest(url)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer r.Body.Close()
+
+	bodyReader, err := common.DecodeHTML(r.Body, r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("decode failed: %w", err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("goquery parse failed: %w", err)
+	}
+
+	return doc, nil
+}
+
 func makeRequest(url string) (*http.Response, error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -112,7 +105,12 @@ func makeRequest(url string) (*http.Response, error) {
 		return nil, err
 	}
 
+	if r.StatusCode == http.StatusNotFound {
+		r.Body.Close()
+		return nil, fmt.Errorf("status code: %d, %w", r.StatusCode, errNotFound)
+	}
 	if r.StatusCode != 200 {
+		r.Body.Close()
 		return nil, fmt.Errorf("status code: %d", r.StatusCode)
 	}
 
@@ -0,0 +1,102 @@
+package progarchives
+
+import (
+	"fiatjaf/wiki-importer/common"
+
+	"github.com/nbd-wtf/go-nostr/nip54"
+)
+
+// identifierIndex remembers the identifier each album or artist was
+// published under, so that links from other articles point exactly at it.
+// An item keeps its identifier once it has one; when the one for its title
+// is already taken by another ID, qualifiers are appended and the
+// progarchives ID is the last resort.
+type identifierIndex struct {
+	*common.IdentifierIndex[uint64]
+}
+
+// Indexes are the identifiers of both kinds of articles, which link to
+// each other
+type Indexes struct {
+	Albums  *identifierIndex
+	Artists *identifierIndex
+}
+
+func loadIndexes() (Indexes, error) {
+	albums, err := loadIdentifierIndex("progarchives-albums.jsonl")
+	if err != nil {
+		return Indexes{}, err
+	}
+
+	artists, err := loadIdentifierIndex("progarchives-artists.jsonl")
+	if err != nil {
+		albums.Close()
+		return Indexes{}, err
+	}
+
+	return Indexes{Albums: albums, Artists: artists}, nil
+}
+
+func (i Indexes) Close() {
+	i.Albums.Close()
+	i.Artists.Close()
+}
+
+func loadIdentifierIndex(name string) (*identifierIndex, error) {
+	idx, err := common.LoadIdentifierIndex[uint64](name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &identifierIndex{idx}, nil
+}
+
+// identifier is where an item was published, or where it will be if
+// nothing else takes the identifier for its title first
+func (idx *identifierIndex) identifier(id uint64, title string) string {
+	if identifier, ok := idx.Lookup(id); ok {
+		return identifier
+	}
+
+	return nip54.NormalizeIdentifier(title)
+}
+
+// assign returns the identifier for an item, recording it when it's new
+func (idx *identifierIndex) assign(id uint64, title string, qualifiers ...string) (string, error) {
+	if identifier, ok := idx.Lookup(id); ok {
+		return identifier, nil
+	}
+
+	entry := common.IdentifierEntry[uint64]{
+		ID:         id,
+		Title:      title,
+		Bare:       nip54.NormalizeIdentifier(title),
+		Qualifiers: qualifiers,
+	}
+
+	entry.Identifier = entry.Bare
+	if idx.Taken(entry.Bare, id) {
+		entry.Identifier = idx.Qualify(entry)
+	}
+
+	return entry.Identifier, idx.Record(entry)
+}
+
+// albumTitle names an album after its artist too, so albums with the same
+// name by different artists get different identifiers
+func albumTitle(title string, artist string) string {
+	if artist == "" {
+		return title + " (album)"
+	}
+
+	return title + " (" + artist + " album)"
+}
+
+// wikilink links to an identifier showing a label
+func wikilink(identifier string, label string) string {
+	if nip54.NormalizeIdentifier(label) == identifier {
+		return "[[" + label + "]]"
+	}
+
+	return "[[" + identifier + "|" + label + "]]"
+}
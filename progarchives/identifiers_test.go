@@ -0,0 +1,83 @@
+package progarchives
+
+import (
+	"os"
+	"testing"
+)
+
+func chdirTemp(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestIdentifierIndex(t *testing.T) {
+	chdirTemp(t)
+
+	idx, err := loadIdentifierIndex("test.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// two bands with a self-titled album each
+	first, err := idx.assign(10, albumTitle("Nektar", "Nektar"), "1971")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != "nektar--nektar-album-" {
+		t.Errorf("first = %s", first)
+	}
+
+	second, err := idx.assign(11, albumTitle("Nektar", "Nektar"), "1971")
+	if err != nil {
+		t.Fatal(err)
+	}
+	third, err := idx.assign(12, albumTitle("Nektar", "Nektar"), "1971")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second != "nektar--nektar-album-1971" || third != "nektar--nektar-album-1971-12" {
+		t.Errorf("second = %s, third = %s", second, third)
+	}
+
+	if again, _ := idx.assign(11, "Something else"); again != second {
+		t.Errorf("reassigned %s", again)
+	}
+	if identifier := idx.identifier(99, albumTitle("Foxtrot", "Genesis")); identifier != "foxtrot--genesis-album-" {
+		t.Errorf("unknown album = %s", identifier)
+	}
+
+	// artists keep the identifier plain wikilinks to their names resolve to,
+	// only what's appended to it loses the extra dashes
+	if elp, _ := idx.assign(20, "Emerson, Lake & Palmer", "United Kingdom"); elp != "emerson--lake---palmer" {
+		t.Errorf("artist = %s", elp)
+	}
+	if other, _ := idx.assign(21, "Emerson, Lake & Palmer", "Côte d'Ivoire"); other != "emerson--lake---palmer-côte-d-ivoire" {
+		t.Errorf("other artist = %s", other)
+	}
+	idx.Close()
+
+	idx, err = loadIdentifierIndex("test.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	if identifier, ok := idx.Lookup(12); !ok || identifier != third {
+		t.Errorf("reloaded %s, %v", identifier, ok)
+	}
+}
+
+func TestWikilink(t *testing.T) {
+	if link := wikilink("genesis", "Genesis"); link != "[[Genesis]]" {
+		t.Errorf("link = %s", link)
+	}
+	if link := wikilink("foxtrot--genesis-album-", "Foxtrot"); link != "[[foxtrot--genesis-album-|Foxtrot]]" {
+		t.Errorf("link = %s", link)
+	}
+}
@@ -2,6 +2,8 @@ package progarchives
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"time"
 
@@ -42,13 +44,25 @@ func getCommonConfig(ctx context.Context) (*CommonConfig, error) {
 	}, nil
 }
 
-// FetchFunc represents a function that fetches data by ID
-type FetchFunc func(id uint64) (title string, asciiDoc string, err error)
+// Article is a wiki article to publish
+type Article struct {
+	Title string
+	// the d tag, from the title when empty
+	Identifier string
+	AsciiDoc   string
+}
+
+// FetchFunc represents a function that fetches data by ID, returning the
+// main article first and any articles that go along with it after
+type FetchFunc func(id uint64) ([]Article, error)
 
 type RunParams struct {
 	Start uint64
 	End   uint64
 	Fetch FetchFunc
+	// IDs to skip, and where to record the ones found missing; nil to
+	// fetch every ID
+	Missing *missingIDs
 }
 
 func run(ctx context.Context, params *RunParams) error {
@@ -58,55 +72,69 @@ func run(ctx context.Context, params *RunParams) error {
 	}
 
 	for i := params.Start; i <= params.End; i++ {
+		if params.Missing != nil && params.Missing.has(i) {
+			continue
+		}
+
 		logger.Printf("Processing ID %d\n", i)
 
-		title, asciiDoc, err := params.Fetch(i)
+		articles, err := params.Fetch(i)
 		if err != nil {
 			logger.Printf("Error fetching ID %d: %v\n", i, err)
 
+			if params.Missing != nil && errors.Is(err, errNotFound) {
+				if err := params.Missing.add(i); err != nil {
+					return err
+				}
+			}
+
 			time.Sleep(2 * time.Second)
 
 			// Try with the next one
 			continue
 		}
 
-		logger.Printf("Successfully fetched: %s\n", title)
+		for _, article := range articles {
+			logger.Printf("Successfully fetched: %s\n", article.Title)
 
-		evt := nostr.Event{
-			CreatedAt: nostr.Now(),
-			Kind:      30818,
-			Tags: nostr.Tags{
-				{"title", title},
-				{"d", nip54.NormalizeIdentifier(title)},
-			},
-			Content: asciiDoc,
-		}
+			if err := publish(cfg, article); err != nil {
+				logger.Printf("Error publishing %s: %v\n", article.Title, err)
 
-		if err := evt.Sign(cfg.NostrKey); err != nil {
-			logger.Printf("Error signing event for %s: %v\n", title, err)
+				// Try with the next one
+				continue
+			}
 
-			// Try with the next one
-			continue
+			logger.Printf("Successfully published: %s\n", article.Title)
 		}
 
-		relay, err := cfg.Pool.EnsureRelay(cfg.RelayURL)
-		if err != nil {
-			logger.Printf("Error ensuring relay for %s: %v\n", title, err)
+		time.Sleep(2 * time.Second)
+	}
 
-			// Try with the next one
-			continue
-		}
+	return nil
+}
 
-		if err := relay.Publish(cfg.Ctx, evt); err != nil {
-			logger.Printf("Error publishing %s: %v\n", title, err)
+func publish(cfg *CommonConfig, article Article) error {
+	evt := nostr.Event{
+		CreatedAt: nostr.Now(),
+		Kind:      30818,
+		Tags: nostr.Tags{
+			{"title", article.Title},
+			{"d", firstNonEmpty(article.Identifier, nip54.NormalizeIdentifier(article.Title))},
+		},
+		Content: article.AsciiDoc,
+	}
 
-			// Try with the next one
-			continue
-		}
+	if err := evt.Sign(cfg.NostrKey); err != nil {
+		return fmt.Errorf("sign event: %w", err)
+	}
 
-		logger.Printf("Successfully published: %s\n", title)
+	relay, err := cfg.Pool.EnsureRelay(cfg.RelayURL)
+	if err != nil {
+		return fmt.Errorf("ensure relay: %w", err)
+	}
 
-		time.Sleep(2 * time.Second)
+	if err := relay.Publish(cfg.Ctx, evt); err != nil {
+		return fmt.Errorf("publish: %w", err)
 	}
 
 	return nil
@@ -115,19 +143,95 @@ func run(ctx context.Context, params *RunParams) error {
 func HandleAlbums(ctx context.Context, l *log.Logger, c *cli.Command) error {
 	logger = l
 
+	options := AlbumOptions{
+		Reviews:    c.Bool("reviews"),
+		MaxReviews: int(c.Uint("max-reviews")),
+	}
+
+	missing, err := loadMissingIDs("album")
+	if err != nil {
+		return err
+	}
+	defer missing.Close()
+
+	indexes, err := loadIndexes()
+	if err != nil {
+		return err
+	}
+	defer indexes.Close()
+
 	return run(ctx, &RunParams{
 		Start: c.Uint("continue"),
-		End:   75959,
-		Fetch: album,
+		End:   lastID(c, "album", knownLastAlbum),
+		Fetch: func(id uint64) ([]Article, error) {
+			return album(id, options, indexes)
+		},
+		Missing: missing,
 	})
 }
 
 func HandleArtists(ctx context.Context, l *log.Logger, c *cli.Command) error {
 	logger = l
 
+	missing, err := loadMissingIDs("artist")
+	if err != nil {
+		return err
+	}
+	defer missing.Close()
+
+	indexes, err := loadIndexes()
+	if err != nil {
+		return err
+	}
+	defer indexes.Close()
+
 	return run(ctx, &RunParams{
 		Start: c.Uint("continue"),
-		End:   12736,
-		Fetch: artist,
+		End:   lastID(c, "artist", knownLastArtist),
+		Fetch: func(id uint64) ([]Article, error) {
+			return artist(id, indexes)
+		},
+		Missing: missing,
+	})
+}
+
+// lastID is the --end flag, or the highest ID on the site when it's not set
+func lastID(c *cli.Command, kind string, known uint64) uint64 {
+	if end := c.Uint("end"); end > 0 {
+		return end
+	}
+
+	last, err := discoverLastID(latestAdditionsUrl, kind, known)
+	if err != nil {
+		logger.Printf("Error discovering the last %s ID, stopping at %d: %v\n", kind, last, err)
+	} else {
+		logger.Printf("Last %s ID is %d\n", kind, last)
+	}
+
+	return last
+}
+
+func HandleGenres(ctx context.Context, l *log.Logger, c *cli.Command) error {
+	logger = l
+
+	indexes, err := loadIndexes()
+	if err != nil {
+		return err
+	}
+	defer indexes.Close()
+
+	// the styles in between that don't exist fail and are skipped
+	last, err := discoverLastID(subgenresUrl, "subgenre", 0)
+	if err != nil {
+		return err
+	}
+	logger.Printf("Last subgenre ID is %d\n", last)
+
+	return run(ctx, &RunParams{
+		Start: max(c.Uint("continue"), 1),
+		End:   last,
+		Fetch: func(id uint64) ([]Article, error) {
+			return subgenre(id, indexes)
+		},
 	})
 }
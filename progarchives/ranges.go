@@ -0,0 +1,148 @@
+package progarchives
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"fiatjaf/wiki-importer/common"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// errNotFound is returned for IDs progarchives answers with a 404, usually
+// because what they were was deleted or merged into something else. Pages
+// that merely look wrong (maintenance, rate limits) are plain errors so that
+// their IDs are tried again.
+var errNotFound = errors.New("not found")
+
+// the highest IDs when these were last hard-coded, discovery never goes
+// below them
+const (
+	knownLastAlbum  = 75959
+	knownLastArtist = 12736
+)
+
+const (
+	// latestAdditionsUrl lists the latest albums and artists added to the site
+	latestAdditionsUrl = "https://www.progarchives.com/"
+	// subgenresUrl lists every subgenre
+	subgenresUrl = "https://www.progarchives.com/subgenre.asp"
+)
+
+// links to subgenres give their ID as the style
+var linkIDRegex = regexp.MustCompile(`(?i)(\w+)\.asp\?(?:id|style)=(\d+)`)
+
+// discoverLastID finds the highest ID linked from a page for a page kind
+// like "album", "artist" or "subgenre", or known when it can't be found
+func discoverLastID(page string, kind string, known uint64) (uint64, error) {
+	doc, err := fetchDocument(page)
+	if err != nil {
+		return known, fmt.Errorf("fetch %s: %w", page, err)
+	}
+
+	return max(maxLinkedID(doc, kind), known), nil
+}
+
+func maxLinkedID(doc *goquery.Document, kind string) uint64 {
+	var last uint64
+
+	doc.Find(`a[href]`).Each(func(i int, link *goquery.Selection) {
+		href, _ := link.Attr("href")
+		match := linkIDRegex.FindStringSubmatch(href)
+		if match == nil || !strings.EqualFold(match[1], kind) {
+			return
+		}
+
+		if id, err := strconv.ParseUint(match[2], 10, 64); err == nil {
+			last = max(last, id)
+		}
+	})
+
+	return last
+}
+
+// linkedID is the ID in a link like "artist.asp?id=123"
+func linkedID(link *goquery.Selection) uint64 {
+	href, _ := link.Attr("href")
+	if match := linkIDRegex.FindStringSubmatch(href); match != nil {
+		id, _ := strconv.ParseUint(match[2], 10, 64)
+		return id
+	}
+
+	return 0
+}
+
+// missingIDs remembers the IDs that had no page, so later runs don't fetch
+// them again. It is kept as one ID per line; deleting the file retries them.
+type missingIDs struct {
+	file *os.File
+	ids  map[uint64]bool
+}
+
+func loadMissingIDs(kind string) (*missingIDs, error) {
+	path, err := common.StatePath("progarchives-missing-" + kind + "s.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	m := &missingIDs{ids: make(map[uint64]bool)}
+
+	f, err := os.Open(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("open missing IDs: %w", err)
+	}
+	if err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+
+			id, err := strconv.ParseUint(line, 10, 64)
+			if err != nil {
+				f.Close()
+				return nil, fmt.Errorf("decode missing IDs: %w", err)
+			}
+			m.ids[id] = true
+		}
+		f.Close()
+
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("read missing IDs: %w", err)
+		}
+	}
+
+	m.file, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open missing IDs: %w", err)
+	}
+
+	return m, nil
+}
+
+func (m *missingIDs) Close() error {
+	return m.file.Close()
+}
+
+func (m *missingIDs) has(id uint64) bool {
+	return m.ids[id]
+}
+
+func (m *missingIDs) add(id uint64) error {
+	if m.ids[id] {
+		return nil
+	}
+
+	if _, err := fmt.Fprintln(m.file, id); err != nil {
+		return fmt.Errorf("record missing ID: %w", err)
+	}
+	m.ids[id] = true
+
+	return nil
+}
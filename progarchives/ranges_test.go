@@ -0,0 +1,87 @@
+package progarchives
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestMaxLinkedID(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`
+		<a href="album.asp?id=80012">New album</a>
+		<a href="https://www.progarchives.com/album.asp?id=80101">Newer album</a>
+		<a href="artist.asp?id=13002">New artist</a>
+		<a href="Review.asp?id=999999">Review</a>
+		<a href="subgenre.asp?style=12">Symphonic Prog</a>
+		<a href="/subgenre.asp?style=44">Progressive Metal</a>
+	`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if last := maxLinkedID(doc, "album"); last != 80101 {
+		t.Errorf("last album = %d", last)
+	}
+	if last := maxLinkedID(doc, "artist"); last != 13002 {
+		t.Errorf("last artist = %d", last)
+	}
+	if last := maxLinkedID(doc, "subgenre"); last != 44 {
+		t.Errorf("last subgenre = %d", last)
+	}
+}
+
+func TestMissingIDs(t *testing.T) {
+	chdirTemp(t)
+
+	missing, err := loadMissingIDs("album")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missing.has(12) {
+		t.Error("empty list has 12")
+	}
+	if err := missing.add(12); err != nil {
+		t.Fatal(err)
+	}
+	if err := missing.add(12); err != nil {
+		t.Fatal(err)
+	}
+	missing.Close()
+
+	missing, err = loadMissingIDs("album")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer missing.Close()
+
+	if !missing.has(12) || len(missing.ids) != 1 {
+		t.Errorf("reloaded %v", missing.ids)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/album.asp" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`<html><body><p>The site is under maintenance</p></body></html>`))
+	}))
+	defer server.Close()
+
+	if _, err := fetchDocument(server.URL + "/album.asp"); !errors.Is(err, errNotFound) {
+		t.Errorf("404 gave %v", err)
+	}
+
+	doc, err := fetchDocument(server.URL + "/maintenance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := getTitle(doc); err == nil || errors.Is(err, errNotFound) {
+		t.Errorf("page without a title gave %v, it must be retried", err)
+	}
+}
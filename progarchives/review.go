@@ -0,0 +1,190 @@
+package progarchives
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/nbd-wtf/go-nostr/nip54"
+	"golang.org/x/net/html"
+)
+
+// RatingSummary is the average of the ratings an album got and how they
+// are spread over the five levels
+type RatingSummary struct {
+	Average string
+	Count   int
+	Levels  []RatingLevel
+}
+
+type RatingLevel struct {
+	// "Essential: a masterpiece of progressive rock"
+	Label   string
+	Percent int
+}
+
+// Review is one user review of an album
+type Review struct {
+	Author string
+	// 1 to 5, 0 when the page doesn't say
+	Stars int
+	Date  string
+	Text  string
+}
+
+// excerptLength is how much of each review goes into the reviews article
+const excerptLength = 600
+
+var (
+	ratingLevelRegex = regexp.MustCompile(`^((?:Essential|Excellent|Good|Collectors|Poor)\b[^()]*?)\s*\((\d+)%\)`)
+	starsRegex       = regexp.MustCompile(`(\d)\s*stars?`)
+	postedRegex      = regexp.MustCompile(`Posted\s+(?:\w+,\s+)?(\w+\s+\d{1,2},\s+\d{4})`)
+)
+
+func parseRatingSummary(doc *goquery.Document) *RatingSummary {
+	average := strings.TrimSpace(doc.Find(`span[id^="avgRatings"]`).First().Text())
+	if average == "" {
+		return nil
+	}
+
+	summary := &RatingSummary{Average: average}
+	summary.Count, _ = strconv.Atoi(strings.ReplaceAll(strings.TrimSpace(doc.Find(`span[id^="nbRatings"]`).First().Text()), ",", ""))
+
+	for _, line := range sectionLines(doc.Find(`body`)) {
+		if match := ratingLevelRegex.FindStringSubmatch(line); match != nil {
+			percent, _ := strconv.Atoi(match[2])
+			summary.Levels = append(summary.Levels, RatingLevel{
+				Label:   strings.TrimSpace(match[1]),
+				Percent: percent,
+			})
+		}
+	}
+
+	return summary
+}
+
+// parseReviews finds the reviews on an album page, each in a block with
+// the reviewer's link, the stars given and the text, keeping at most max of
+// them or all when max is 0
+func parseReviews(doc *goquery.Document, max int) []Review {
+	var reviews []Review
+	seen := make(map[*html.Node]bool)
+
+	doc.Find(`a[href*="Collaborators.asp"]`).EachWithBreak(func(i int, link *goquery.Selection) bool {
+		if max > 0 && len(reviews) >= max {
+			return false
+		}
+
+		container := link.Closest(`div`)
+		stars := container.Find(`img[alt*="star"]`)
+		if container.Length() == 0 || stars.Length() == 0 || seen[container.Get(0)] {
+			return true
+		}
+		seen[container.Get(0)] = true
+
+		review := Review{Author: strings.TrimSpace(link.Text())}
+
+		alt, _ := stars.First().Attr("alt")
+		if match := starsRegex.FindStringSubmatch(alt); match != nil {
+			review.Stars, _ = strconv.Atoi(match[1])
+			// the star row has five places, whatever the image says
+			if review.Stars > 5 {
+				review.Stars = 5
+			}
+		}
+
+		var text []string
+		for _, line := range sectionLines(container.Contents()) {
+			if match := postedRegex.FindStringSubmatch(line); match != nil {
+				review.Date = match[1]
+				continue
+			}
+			// the author's name on a line of its own, not lines mentioning it
+			if line == review.Author || strings.Contains(strings.ToLower(line), "permalink") {
+				continue
+			}
+			text = append(text, line)
+		}
+
+		review.Text = excerpt(strings.Join(text, " "), excerptLength)
+		if review.Text != "" {
+			reviews = append(reviews, review)
+		}
+
+		return true
+	})
+
+	return reviews
+}
+
+// excerpt cuts text after the last whole word that fits in length
+func excerpt(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+
+	cut := string(runes[:length])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
+
+func (r RatingSummary) asciiDoc() string {
+	content := strings.Builder{}
+
+	content.WriteString("\n== Rating\n\n")
+	content.WriteString(fmt.Sprintf("Average rating:: %s out of 5", r.Average))
+	if r.Count > 0 {
+		content.WriteString(fmt.Sprintf(", from %d ratings", r.Count))
+	}
+	content.WriteByte('\n')
+
+	if len(r.Levels) > 0 {
+		content.WriteString("\n[cols=\"3,1\"]\n|===\n")
+		for _, level := range r.Levels {
+			content.WriteString(fmt.Sprintf("|%s |%d%%\n", level.Label, level.Percent))
+		}
+		content.WriteString("|===\n")
+	}
+
+	return content.String()
+}
+
+// reviewsArticle quotes the reviews of an album, each attributed to its
+// author
+func reviewsArticle(a Album, reviews []Review) Article {
+	content := strings.Builder{}
+
+	content.WriteString("Reviews of " + a.link())
+	if a.Artist != "" {
+		content.WriteString(" by " + a.artistLink())
+	}
+	content.WriteString(" from progarchives.\n")
+
+	for _, review := range reviews {
+		content.WriteString(fmt.Sprintf("\n== %s\n\n", review.Author))
+
+		if review.Stars > 0 {
+			content.WriteString(strings.Repeat("★", review.Stars) + strings.Repeat("☆", 5-review.Stars))
+			if review.Date != "" {
+				content.WriteString(", " + review.Date)
+			}
+			content.WriteString("\n\n")
+		} else if review.Date != "" {
+			content.WriteString(review.Date + "\n\n")
+		}
+
+		content.WriteString(fmt.Sprintf("[quote, \"%s\", progarchives]\n____\n%s\n____\n", review.Author, review.Text))
+	}
+
+	return Article{
+		Title:      albumTitle(a.Title, a.Artist) + " reviews",
+		Identifier: firstNonEmpty(a.Identifier, nip54.NormalizeIdentifier(albumTitle(a.Title, a.Artist))) + "-reviews",
+		AsciiDoc:   content.String(),
+	}
+}